@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ANSI terminal output - text-mode cells to escape sequences
+
+type TTextBuffer [25][160]byte
+
+// PC attribute colour order -> ANSI SGR colour order
+var ansiColorMap = [8]byte{0, 4, 2, 6, 1, 5, 3, 7}
+
+// Control characters which terminals interpret rather than display.
+var cp437SafeMap = map[byte]byte{
+	0x00: ' ',
+	0x07: '\xf9',
+	0x08: '\xdb',
+	0x09: 'o',
+	0x0A: '\xdb',
+	0x0D: '\x0e',
+	0x1A: '>',
+	0x1B: '<',
+}
+
+// CP437 -> Unicode, for terminals which expect UTF-8.
+var cp437UnicodeMap = [256]rune{
+	' ', '☺', '☻', '♥', '♦', '♣', '♠', '•', '◘', '○', '◙', '♂', '♀', '♪', '♫', '☼',
+	'►', '◄', '↕', '‼', '¶', '§', '▬', '↨', '↑', '↓', '→', '←', '∟', '↔', '▲', '▼',
+	' ', '!', '"', '#', '$', '%', '&', '\'', '(', ')', '*', '+', ',', '-', '.', '/',
+	'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', ':', ';', '<', '=', '>', '?',
+	'@', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O',
+	'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '\\', ']', '^', '_',
+	'`', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o',
+	'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{', '|', '}', '~', '⌂',
+	'Ç', 'ü', 'é', 'â', 'ä', 'à', 'å', 'ç', 'ê', 'ë', 'è', 'ï', 'î', 'ì', 'Ä', 'Å',
+	'É', 'æ', 'Æ', 'ô', 'ö', 'ò', 'û', 'ù', 'ÿ', 'Ö', 'Ü', '¢', '£', '¥', '₧', 'ƒ',
+	'á', 'í', 'ó', 'ú', 'ñ', 'Ñ', 'ª', 'º', '¿', '⌐', '¬', '½', '¼', '¡', '«', '»',
+	'░', '▒', '▓', '│', '┤', '╡', '╢', '╖', '╕', '╣', '║', '╗', '╝', '╜', '╛', '┐',
+	'└', '┴', '┬', '├', '─', '┼', '╞', '╟', '╚', '╔', '╩', '╦', '╠', '═', '╬', '╧',
+	'╨', '╤', '╥', '╙', '╘', '╒', '╓', '╫', '╪', '┘', '┌', '█', '▄', '▌', '▐', '▀',
+	'α', 'ß', 'Γ', 'π', 'Σ', 'σ', 'µ', 'τ', 'Φ', 'Θ', 'Ω', 'δ', '∞', 'φ', 'ε', '∩',
+	'≡', '±', '≥', '≤', '⌠', '⌡', '÷', '≈', '°', '∙', '·', '√', 'ⁿ', '²', '■', ' ',
+}
+
+type AnsiEncoder struct {
+	CharMap          func(ch byte) string
+	cursorX, cursorY int
+	attr             int
+}
+
+func AnsiCharCP437(ch byte) string {
+	if v, ok := cp437SafeMap[ch]; ok {
+		ch = v
+	}
+	return string([]byte{ch})
+}
+
+func AnsiCharUnicode(ch byte) string {
+	return string(cp437UnicodeMap[ch])
+}
+
+func NewAnsiEncoder(charMap func(ch byte) string) *AnsiEncoder {
+	e := &AnsiEncoder{CharMap: charMap}
+	e.cursorX = -1
+	e.cursorY = -1
+	e.attr = -1
+	return e
+}
+
+func AnsiAttrSGR(attr byte) string {
+	var sb strings.Builder
+	sb.WriteString("\x1b[0;")
+	if (attr & 0x08) != 0 {
+		sb.WriteString("1;")
+	}
+	if (attr & 0x80) != 0 {
+		sb.WriteString("5;")
+	}
+	sb.WriteString("3")
+	sb.WriteByte('0' + ansiColorMap[attr&0x07])
+	sb.WriteString(";4")
+	sb.WriteByte('0' + ansiColorMap[(attr>>4)&0x07])
+	sb.WriteByte('m')
+	return sb.String()
+}
+
+func (e *AnsiEncoder) Reset(sb *strings.Builder) {
+	sb.WriteString("\x1b[0m\x1b[2J\x1b[H")
+	e.cursorX = 0
+	e.cursorY = 0
+	e.attr = 0x07
+}
+
+func (e *AnsiEncoder) MoveTo(sb *strings.Builder, x, y int) {
+	if e.cursorX == x && e.cursorY == y {
+		return
+	}
+	if e.cursorY == y && e.cursorX >= 0 && x > e.cursorX && x-e.cursorX <= 3 {
+		sb.WriteString("\x1b[" + strconv.Itoa(x-e.cursorX) + "C")
+	} else {
+		sb.WriteString("\x1b[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H")
+	}
+	e.cursorX = x
+	e.cursorY = y
+}
+
+func (e *AnsiEncoder) SetAttr(sb *strings.Builder, attr byte) {
+	if e.attr != int(attr) {
+		sb.WriteString(AnsiAttrSGR(attr))
+		e.attr = int(attr)
+	}
+}
+
+func (e *AnsiEncoder) PutCell(sb *strings.Builder, x, y int, ch, attr byte) {
+	// Writing the bottom-right cell scrolls many terminals.
+	if x >= 79 && y >= 24 {
+		return
+	}
+	e.MoveTo(sb, x, y)
+	e.SetAttr(sb, attr)
+	sb.WriteString(e.CharMap(ch))
+	e.cursorX++
+	if e.cursorX >= 80 {
+		// The cursor position after writing the last column differs
+		// between terminals; force an absolute move next time.
+		e.cursorX = -1
+		e.cursorY = -1
+	}
+}
+
+// WriteDiff emits the cells of cur which differ from prev, updating prev.
+func (e *AnsiEncoder) WriteDiff(sb *strings.Builder, prev, cur *TTextBuffer, columns int) {
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < columns; ix++ {
+			if prev[iy][ix*2] != cur[iy][ix*2] || prev[iy][ix*2+1] != cur[iy][ix*2+1] {
+				e.PutCell(sb, ix, iy, cur[iy][ix*2], cur[iy][ix*2+1])
+				prev[iy][ix*2] = cur[iy][ix*2]
+				prev[iy][ix*2+1] = cur[iy][ix*2+1]
+			}
+		}
+	}
+}
+
+// WriteFull emits every cell of cur, updating prev.
+func (e *AnsiEncoder) WriteFull(sb *strings.Builder, prev, cur *TTextBuffer, columns int) {
+	e.Reset(sb)
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < columns; ix++ {
+			e.PutCell(sb, ix, iy, cur[iy][ix*2], cur[iy][ix*2+1])
+		}
+	}
+	*prev = *cur
+}
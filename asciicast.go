@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Asciicast v2 recording - screen changes as timed ANSI output
+
+// Changes closer together than this are merged into one event.
+const asciicastMergeWindow = 10 * time.Millisecond
+
+type AsciicastWriter struct {
+	Clock        func() time.Duration
+	file         io.WriteCloser
+	writer       *bufio.Writer
+	encoder      *AnsiEncoder
+	screen, sent TTextBuffer
+	columns      int
+	pending      strings.Builder
+	pendingTime  time.Duration
+}
+
+func NewAsciicastWriter(w io.WriteCloser, clock func() time.Duration) (*AsciicastWriter, error) {
+	a := &AsciicastWriter{
+		Clock:   clock,
+		file:    w,
+		writer:  bufio.NewWriter(w),
+		encoder: NewAnsiEncoder(AnsiCharUnicode),
+		columns: 80,
+	}
+	_, err := a.writer.WriteString("{\"version\": 2, \"width\": 80, \"height\": 25, " +
+		"\"timestamp\": " + strconv.FormatInt(time.Now().Unix(), 10) + ", " +
+		"\"title\": \"OpenZoo/Go\", \"env\": {\"TERM\": \"xterm-256color\"}}\n")
+	if err != nil {
+		return nil, err
+	}
+	a.encoder.Reset(&a.pending)
+	a.pending.WriteString("\x1b[?25l")
+	return a, nil
+}
+
+func AsciicastCreate(filename string, clock func() time.Duration) (*AsciicastWriter, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	a, err := NewAsciicastWriter(f, clock)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// asciicastQuote quotes s as a JSON string.
+func asciicastQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			sb.WriteString("\\\"")
+		case r == '\\':
+			sb.WriteString("\\\\")
+		case r < 0x20 || r == 0x7F:
+			sb.WriteString("\\u00")
+			sb.WriteByte("0123456789abcdef"[r>>4])
+			sb.WriteByte("0123456789abcdef"[r&15])
+		case r == utf8.RuneError:
+			sb.WriteRune('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func (a *AsciicastWriter) writeEvent() {
+	if a.pending.Len() == 0 {
+		return
+	}
+	a.writer.WriteString("[" + strconv.FormatFloat(a.pendingTime.Seconds(), 'f', 6, 64) +
+		", \"o\", " + asciicastQuote(a.pending.String()) + "]\n")
+	a.pending.Reset()
+}
+
+// begin starts a new event, unless the pending one is recent enough to be
+// added to.
+func (a *AsciicastWriter) begin() {
+	now := a.Clock()
+	if a.pending.Len() > 0 && now-a.pendingTime >= asciicastMergeWindow {
+		a.writeEvent()
+	}
+	if a.pending.Len() == 0 {
+		a.pendingTime = now
+	}
+}
+
+func (a *AsciicastWriter) update() {
+	a.begin()
+	a.encoder.WriteDiff(&a.pending, &a.sent, &a.screen, a.columns)
+}
+
+func (a *AsciicastWriter) VideoSetMode(columns int) {
+	a.columns = columns
+	a.begin()
+	a.encoder.Reset(&a.pending)
+	a.sent = TTextBuffer{}
+}
+
+func (a *AsciicastWriter) VideoClrScr(backgroundColor uint8) {
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < a.columns; ix++ {
+			a.screen[iy][ix*2] = ' '
+			a.screen[iy][ix*2+1] = backgroundColor << 4
+		}
+	}
+	a.update()
+}
+
+func (a *AsciicastWriter) VideoWriteText(x, y int16, color byte, text string) {
+	if y < 0 || y >= 25 {
+		return
+	}
+	for i := 0; i < len(text); i++ {
+		a.screen[y][x*2] = text[i]
+		a.screen[y][x*2+1] = color
+		x++
+		if x >= int16(a.columns) {
+			x = 0
+			y++
+			if y >= 25 {
+				break
+			}
+		}
+	}
+	a.update()
+}
+
+func (a *AsciicastWriter) VideoMove(x, y, width int16, buffer []byte) {
+	if width > int16(len(buffer)>>1) {
+		width = int16(len(buffer) >> 1)
+	}
+	copy(a.screen[y][int(x)*2:(int(x)+int(width))*2], buffer)
+	a.update()
+}
+
+func (a *AsciicastWriter) Close() error {
+	a.pending.WriteString("\x1b[0m\x1b[?25h\x1b[25;1H\r\n")
+	a.writeEvent()
+	if err := a.writer.Flush(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
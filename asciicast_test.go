@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (n *nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestAsciicastQuote(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("\"a\\\"\\\\\\u001b[0m\\u000d\"", asciicastQuote("a\"\\\x1b[0m\r"))
+	assert.Equal("\"☺░\"", asciicastQuote(AnsiCharUnicode(0x01)+AnsiCharUnicode(0xB0)))
+}
+
+func TestAsciicastWriter(t *testing.T) {
+	assert := assert.New(t)
+	var out nopWriteCloser
+	var now time.Duration
+	a, err := NewAsciicastWriter(&out, func() time.Duration { return now })
+	assert.Nil(err)
+
+	a.VideoWriteText(0, 0, 0x1F, "Hi")
+	a.VideoWriteText(2, 0, 0x1F, "!")
+	now = time.Second
+	a.VideoWriteText(0, 1, 0x1E, "\x02")
+	assert.Nil(a.Close())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(3, len(lines))
+	assert.True(strings.HasPrefix(lines[0], "{\"version\": 2, \"width\": 80, \"height\": 25"))
+	assert.True(strings.HasPrefix(lines[1], "[0.000000, \"o\", "))
+	assert.Contains(lines[1], "Hi!")
+	assert.True(strings.HasPrefix(lines[2], "[1.000000, \"o\", "))
+	assert.Contains(lines[2], "\\u001b[2;1H\\u001b[0;1;33;44m☻")
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/lzw"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Gameplay capture - animated GIF/APNG from the text buffer
+//
+// Frames are pushed from the video thread as copies of the text buffer and
+// rendered and encoded on a goroutine of their own. Only the part of each
+// frame which changed is stored.
+
+const (
+	CAPTURE_GIF = iota
+	CAPTURE_APNG
+)
+
+const (
+	CaptureWidth      = 640
+	CaptureHeight     = 350
+	captureQueueSize  = 64
+	captureFinalDelay = 100 * time.Millisecond
+)
+
+// EGA palette, as RGB
+var capturePalette = [16][3]byte{
+	{0x00, 0x00, 0x00}, {0x00, 0x00, 0xAA}, {0x00, 0xAA, 0x00}, {0x00, 0xAA, 0xAA},
+	{0xAA, 0x00, 0x00}, {0xAA, 0x00, 0xAA}, {0xAA, 0x55, 0x00}, {0xAA, 0xAA, 0xAA},
+	{0x55, 0x55, 0x55}, {0x55, 0x55, 0xFF}, {0x55, 0xFF, 0x55}, {0x55, 0xFF, 0xFF},
+	{0xFF, 0x55, 0x55}, {0xFF, 0x55, 0xFF}, {0xFF, 0xFF, 0x55}, {0xFF, 0xFF, 0xFF},
+}
+
+type (
+	TCaptureFrame struct {
+		Screen  TTextBuffer
+		Columns int
+		Blink   bool
+		Time    time.Duration
+	}
+	TCaptureRect struct {
+		X1, Y1, X2, Y2 int
+	}
+	captureWriter interface {
+		delayUnit() time.Duration
+		writeFrame(pix []byte, rect TCaptureRect, delay time.Duration) error
+		close() error
+	}
+	Capture struct {
+		Filename string
+		frames   chan TCaptureFrame
+		done     chan error
+		writer   captureWriter
+		emitted  []byte
+		first    bool
+	}
+)
+
+// CaptureNextFilename returns the first CAPTUREn file name not yet taken.
+func CaptureNextFilename(format int) string {
+	ext := ".GIF"
+	if format == CAPTURE_APNG {
+		ext = ".PNG"
+	}
+	for i := 1; ; i++ {
+		filename := "CAPTURE" + strconv.Itoa(i) + ext
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return filename
+		}
+	}
+}
+
+func CaptureStart(filename string, format int) (*Capture, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &Capture{
+		Filename: filename,
+		frames:   make(chan TCaptureFrame, captureQueueSize),
+		done:     make(chan error, 1),
+		first:    true,
+	}
+	if format == CAPTURE_APNG {
+		c.writer, err = newCaptureApngWriter(f)
+	} else {
+		c.writer, err = newCaptureGifWriter(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	go c.run()
+	return c, nil
+}
+
+// Push queues a frame for encoding. If the encoder is behind, the frame is
+// dropped rather than holding up the caller.
+func (c *Capture) Push(frame *TCaptureFrame) {
+	select {
+	case c.frames <- *frame:
+	default:
+	}
+}
+
+// Stop finishes the file, waiting for the queued frames to be encoded.
+func (c *Capture) Stop() error {
+	close(c.frames)
+	return <-c.done
+}
+
+// CaptureRender draws a text buffer as palette indices, one byte per pixel.
+func CaptureRender(pix []byte, screen *TTextBuffer, columns int, blink bool) {
+	charWidth := CaptureWidth / columns
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < columns; ix++ {
+			ch := int(screen[iy][ix*2]) * 14
+			co := screen[iy][ix*2+1]
+			if co >= 0x80 {
+				co &= 0x7F
+				if blink {
+					co = (co >> 4) * 0x11
+				}
+			}
+			for ly := 0; ly < 14; ly++ {
+				line := pix[(iy*14+ly)*CaptureWidth+ix*charWidth:]
+				c := charsetData[ch+ly]
+				for lx := 0; lx < charWidth; lx++ {
+					if (c & (0x80 >> (lx * 8 / charWidth))) != 0 {
+						line[lx] = co & 0x0F
+					} else {
+						line[lx] = co >> 4
+					}
+				}
+			}
+		}
+	}
+}
+
+// captureDiffRect returns the bounding box of the pixels which differ.
+func captureDiffRect(prev, cur []byte) (rect TCaptureRect, changed bool) {
+	rect = TCaptureRect{CaptureWidth, CaptureHeight, 0, 0}
+	for y := 0; y < CaptureHeight; y++ {
+		row := y * CaptureWidth
+		if bytes.Equal(prev[row:row+CaptureWidth], cur[row:row+CaptureWidth]) {
+			continue
+		}
+		changed = true
+		rect.Y1 = Min(rect.Y1, y)
+		rect.Y2 = y + 1
+		for x := 0; x < CaptureWidth; x++ {
+			if prev[row+x] != cur[row+x] {
+				rect.X1 = Min(rect.X1, x)
+				rect.X2 = Max(rect.X2, x+1)
+			}
+		}
+	}
+	return
+}
+
+func (c *Capture) emit(pix []byte, delay time.Duration) error {
+	rect := TCaptureRect{0, 0, CaptureWidth, CaptureHeight}
+	if !c.first {
+		var changed bool
+		rect, changed = captureDiffRect(c.emitted, pix)
+		if !changed {
+			rect = TCaptureRect{0, 0, 1, 1}
+		}
+	}
+	c.first = false
+	copy(c.emitted, pix)
+	return c.writer.writeFrame(pix, rect, delay)
+}
+
+func (c *Capture) run() {
+	var err error
+	var pendingTime time.Duration
+	unit := c.writer.delayUnit()
+	cur := make([]byte, CaptureWidth*CaptureHeight)
+	pending := make([]byte, CaptureWidth*CaptureHeight)
+	hasPending := false
+	c.emitted = make([]byte, CaptureWidth*CaptureHeight)
+
+	for frame := range c.frames {
+		if err != nil {
+			continue
+		}
+		CaptureRender(cur, &frame.Screen, frame.Columns, frame.Blink)
+		if hasPending {
+			if bytes.Equal(cur, pending) {
+				continue
+			}
+			// A frame shown for less than the format's delay unit is
+			// replaced by the next one.
+			delay := (frame.Time/unit - pendingTime/unit) * unit
+			if delay > 0 {
+				err = c.emit(pending, delay)
+			}
+		}
+		copy(pending, cur)
+		pendingTime = frame.Time
+		hasPending = true
+	}
+	if err == nil {
+		// An empty capture still gets a (blank) frame.
+		err = c.emit(pending, captureFinalDelay)
+	}
+	if closeErr := c.writer.close(); err == nil {
+		err = closeErr
+	}
+	c.done <- err
+}
+
+// GIF
+
+type captureGifWriter struct {
+	file io.WriteCloser
+	w    *bufio.Writer
+}
+
+// captureGifBlockWriter splits LZW data into GIF sub-blocks.
+type captureGifBlockWriter struct {
+	w   *bufio.Writer
+	buf [255]byte
+	n   int
+}
+
+func (b *captureGifBlockWriter) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); i++ {
+		b.buf[b.n] = p[i]
+		b.n++
+		if b.n == len(b.buf) {
+			b.flush()
+		}
+	}
+	return len(p), nil
+}
+
+func (b *captureGifBlockWriter) flush() {
+	if b.n > 0 {
+		b.w.WriteByte(byte(b.n))
+		b.w.Write(b.buf[:b.n])
+		b.n = 0
+	}
+}
+
+func newCaptureGifWriter(f io.WriteCloser) (captureWriter, error) {
+	g := &captureGifWriter{file: f, w: bufio.NewWriter(f)}
+	g.w.WriteString("GIF89a")
+	binary.Write(g.w, binary.LittleEndian, [2]uint16{CaptureWidth, CaptureHeight})
+	// global colour table of 16 entries
+	g.w.Write([]byte{0xF3, 0, 0})
+	for _, c := range capturePalette {
+		g.w.Write(c[:])
+	}
+	// loop forever
+	g.w.Write([]byte{0x21, 0xFF, 11})
+	g.w.WriteString("NETSCAPE2.0")
+	g.w.Write([]byte{3, 1, 0, 0, 0})
+	return g, nil
+}
+
+func (g *captureGifWriter) delayUnit() time.Duration {
+	return 10 * time.Millisecond
+}
+
+func (g *captureGifWriter) writeFrame(pix []byte, rect TCaptureRect, delay time.Duration) error {
+	delayCs := int(delay / (10 * time.Millisecond))
+	if delayCs > 65535 {
+		delayCs = 65535
+	}
+	// graphic control extension, leaving the previous frame in place
+	g.w.Write([]byte{0x21, 0xF9, 4, 0x04, byte(delayCs), byte(delayCs >> 8), 0, 0})
+	g.w.WriteByte(0x2C)
+	binary.Write(g.w, binary.LittleEndian, [4]uint16{
+		uint16(rect.X1), uint16(rect.Y1), uint16(rect.X2 - rect.X1), uint16(rect.Y2 - rect.Y1),
+	})
+	g.w.WriteByte(0)
+
+	g.w.WriteByte(4)
+	bw := &captureGifBlockWriter{w: g.w}
+	lw := lzw.NewWriter(bw, lzw.LSB, 4)
+	for y := rect.Y1; y < rect.Y2; y++ {
+		if _, err := lw.Write(pix[y*CaptureWidth+rect.X1 : y*CaptureWidth+rect.X2]); err != nil {
+			return err
+		}
+	}
+	if err := lw.Close(); err != nil {
+		return err
+	}
+	bw.flush()
+	return g.w.WriteByte(0)
+}
+
+func (g *captureGifWriter) close() error {
+	g.w.WriteByte(0x3B)
+	if err := g.w.Flush(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
+}
+
+// APNG
+
+type captureApngWriter struct {
+	file       *os.File
+	w          *bufio.Writer
+	frames     uint32
+	sequence   uint32
+	actlOffset int64
+}
+
+func (a *captureApngWriter) writeChunk(name string, data []byte) {
+	binary.Write(a.w, binary.BigEndian, uint32(len(data)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(name))
+	crc.Write(data)
+	a.w.WriteString(name)
+	a.w.Write(data)
+	binary.Write(a.w, binary.BigEndian, crc.Sum32())
+}
+
+func captureApngActl(frames uint32) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:], frames)
+	// loop forever
+	binary.BigEndian.PutUint32(data[4:], 0)
+	return data
+}
+
+func newCaptureApngWriter(f *os.File) (captureWriter, error) {
+	a := &captureApngWriter{file: f, w: bufio.NewWriter(f)}
+	a.w.WriteString("\x89PNG\r\n\x1a\n")
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], CaptureWidth)
+	binary.BigEndian.PutUint32(ihdr[4:], CaptureHeight)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 3 // indexed colour
+	a.writeChunk("IHDR", ihdr)
+
+	// The frame count is not known yet; acTL is rewritten on close.
+	a.actlOffset = int64(8 + 12 + len(ihdr))
+	a.writeChunk("acTL", captureApngActl(0))
+
+	plte := make([]byte, 0, 16*3)
+	for _, c := range capturePalette {
+		plte = append(plte, c[:]...)
+	}
+	a.writeChunk("PLTE", plte)
+	return a, nil
+}
+
+func (a *captureApngWriter) delayUnit() time.Duration {
+	return time.Millisecond
+}
+
+func (a *captureApngWriter) writeFrame(pix []byte, rect TCaptureRect, delay time.Duration) error {
+	delayMs := int(delay / time.Millisecond)
+	if delayMs > 65535 {
+		delayMs = 65535
+	}
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], a.sequence)
+	binary.BigEndian.PutUint32(fctl[4:], uint32(rect.X2-rect.X1))
+	binary.BigEndian.PutUint32(fctl[8:], uint32(rect.Y2-rect.Y1))
+	binary.BigEndian.PutUint32(fctl[12:], uint32(rect.X1))
+	binary.BigEndian.PutUint32(fctl[16:], uint32(rect.Y1))
+	binary.BigEndian.PutUint16(fctl[20:], uint16(delayMs))
+	binary.BigEndian.PutUint16(fctl[22:], 1000)
+	// dispose_op NONE, blend_op SOURCE
+	a.writeChunk("fcTL", fctl)
+	a.sequence++
+
+	var data bytes.Buffer
+	if a.frames > 0 {
+		binary.Write(&data, binary.BigEndian, a.sequence)
+		a.sequence++
+	}
+	zw := zlib.NewWriter(&data)
+	for y := rect.Y1; y < rect.Y2; y++ {
+		// filter type: none
+		zw.Write([]byte{0})
+		zw.Write(pix[y*CaptureWidth+rect.X1 : y*CaptureWidth+rect.X2])
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if a.frames > 0 {
+		a.writeChunk("fdAT", data.Bytes())
+	} else {
+		a.writeChunk("IDAT", data.Bytes())
+	}
+	a.frames++
+	return nil
+}
+
+func (a *captureApngWriter) close() error {
+	a.writeChunk("IEND", nil)
+	err := a.w.Flush()
+	if err == nil {
+		if _, err = a.file.Seek(a.actlOffset, io.SeekStart); err == nil {
+			a.w.Reset(a.file)
+			a.writeChunk("acTL", captureApngActl(a.frames))
+			err = a.w.Flush()
+		}
+	}
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureTestFrames(t *testing.T, format int) string {
+	filename := filepath.Join(t.TempDir(), "capture")
+	c, err := CaptureStart(filename, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame TCaptureFrame
+	frame.Columns = 80
+	frame.Screen[0][0] = 'A'
+	frame.Screen[0][1] = 0x1F
+	c.Push(&frame)
+	frame.Time = 100 * time.Millisecond
+	frame.Screen[12][40*2] = 0x02
+	frame.Screen[12][40*2+1] = 0x8E
+	c.Push(&frame)
+	frame.Time = 200 * time.Millisecond
+	frame.Blink = true
+	c.Push(&frame)
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestCaptureGif(t *testing.T) {
+	assert := assert.New(t)
+	f, err := os.Open(captureTestFrames(t, CAPTURE_GIF))
+	assert.Nil(err)
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	assert.Nil(err)
+	assert.Equal(3, len(g.Image))
+	assert.Equal([]int{10, 10, 10}, g.Delay)
+	assert.Equal(CaptureWidth, g.Config.Width)
+	// The second frame only covers the changed cell.
+	assert.True(g.Image[1].Rect.In(image.Rect(40*8, 12*14, 41*8, 13*14)))
+}
+
+func TestCaptureApng(t *testing.T) {
+	assert := assert.New(t)
+	f, err := os.Open(captureTestFrames(t, CAPTURE_APNG))
+	assert.Nil(err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	assert.Nil(err)
+	assert.Equal(CaptureWidth, img.Bounds().Dx())
+	assert.Equal(CaptureHeight, img.Bounds().Dy())
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal([3]uint32{0, 0, 0xAAAA}, [3]uint32{r, g, b})
+}
+
+func TestCaptureApngChunks(t *testing.T) {
+	assert := assert.New(t)
+	data, err := os.ReadFile(captureTestFrames(t, CAPTURE_APNG))
+	assert.Nil(err)
+	assert.Equal("\x89PNG\r\n\x1a\n", string(data[:8]))
+
+	var names []string
+	var sequence []uint32
+	var delays []uint16
+	for data = data[8:]; len(data) >= 12; {
+		length := binary.BigEndian.Uint32(data)
+		name, chunk := string(data[4:8]), data[8:8+length]
+		assert.Equal(crc32.ChecksumIEEE(data[4:8+length]), binary.BigEndian.Uint32(data[8+length:]), name)
+		names = append(names, name)
+		switch name {
+		case "acTL":
+			assert.Equal(uint32(3), binary.BigEndian.Uint32(chunk), "frame count")
+			assert.Equal(uint32(0), binary.BigEndian.Uint32(chunk[4:]), "loop count")
+		case "fcTL":
+			sequence = append(sequence, binary.BigEndian.Uint32(chunk))
+			delays = append(delays, binary.BigEndian.Uint16(chunk[20:]))
+		case "fdAT":
+			sequence = append(sequence, binary.BigEndian.Uint32(chunk))
+		}
+		data = data[12+length:]
+	}
+	assert.Empty(data)
+	assert.Equal([]string{"IHDR", "acTL", "PLTE", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}, names)
+	assert.Equal([]uint32{0, 1, 2, 3, 4}, sequence)
+	assert.Equal([]uint16{100, 100, 100}, delays)
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	_ "embed"
+)
+
+// 8x14 VGA text mode font, one byte per glyph row.
+//
+//go:embed ascii.chr
+var charsetData []byte
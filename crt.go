@@ -52,7 +52,7 @@ func GotoXY(x, y int) {
 func ClrScr() {
 	line := strings.Repeat(" ", windowMaxX-windowMinX+1)
 	for iy := windowMinY; iy <= windowMaxY; iy++ {
-		IVideoWriteText(int16(windowMinX-1), int16(iy-1), TextAttr, line)
+		videoWriteText(int16(windowMinX-1), int16(iy-1), TextAttr, line)
 	}
 }
 
@@ -76,7 +76,7 @@ func Write(s string) {
 				// TODO: scroll up
 			}
 		default:
-			IVideoWriteText(int16(cursorX)-1, int16(cursorY)-1, TextAttr, s[i:i+1])
+			videoWriteText(int16(cursorX)-1, int16(cursorY)-1, TextAttr, s[i:i+1])
 			cursorX++
 			if cursorX > windowMaxX {
 				Write("\r\n")
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BBS door support - dropfiles and terminal input
+
+const (
+	DOOR_COMM_LOCAL  = 0
+	DOOR_COMM_SERIAL = 1
+	DOOR_COMM_TELNET = 2
+)
+
+type (
+	TDoorInfo struct {
+		CommType   int
+		CommHandle int
+		UserRecord int
+		UserName   string
+		UserAlias  string
+		TimeLeft   time.Duration
+		Node       int
+	}
+	TDoorKey struct {
+		Key   byte
+		Shift bool
+	}
+	DoorInputParser struct {
+		state  int
+		seq    []byte
+		lastCR bool
+	}
+)
+
+const (
+	doorInputNormal = iota
+	doorInputIac
+	doorInputIacOption
+	doorInputIacSub
+	doorInputIacSubIac
+	doorInputEsc
+)
+
+const (
+	TELNET_SE   = 240
+	TELNET_SB   = 250
+	TELNET_WILL = 251
+	TELNET_WONT = 252
+	TELNET_DO   = 253
+	TELNET_DONT = 254
+	TELNET_IAC  = 255
+
+	TELNET_OPT_ECHO     = 1
+	TELNET_OPT_SGA      = 3
+	TELNET_OPT_LINEMODE = 34
+)
+
+// doorTelnetWriter escapes IAC bytes in the output stream.
+type doorTelnetWriter struct {
+	w io.Writer
+}
+
+func (d doorTelnetWriter) Write(p []byte) (int, error) {
+	if _, err := d.w.Write([]byte(strings.ReplaceAll(string(p), "\xff", "\xff\xff"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+var ErrDoorDropfileFormat = errors.New("Unrecognized dropfile!")
+
+func doorReadLines(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	return lines, scanner.Err()
+}
+
+// DoorReadDropfile reads a DOOR32.SYS or DOOR.SYS dropfile, telling the two
+// apart by file name.
+func DoorReadDropfile(filename string) (info TDoorInfo, err error) {
+	lines, err := doorReadLines(filename)
+	if err != nil {
+		return
+	}
+	if strings.EqualFold(filepath.Base(filename), "DOOR32.SYS") {
+		return doorParseDoor32(lines)
+	} else {
+		return doorParseDoorSys(lines)
+	}
+}
+
+func doorParseDoor32(lines []string) (info TDoorInfo, err error) {
+	if len(lines) < 11 {
+		err = ErrDoorDropfileFormat
+		return
+	}
+	info.CommType = Val(lines[0])
+	info.CommHandle = Val(lines[1])
+	info.UserRecord = Val(lines[4])
+	info.UserName = lines[5]
+	info.UserAlias = lines[6]
+	info.TimeLeft = time.Duration(Val(lines[8])) * time.Minute
+	info.Node = Val(lines[10])
+	return
+}
+
+func doorParseDoorSys(lines []string) (info TDoorInfo, err error) {
+	if len(lines) < 20 {
+		err = ErrDoorDropfileFormat
+		return
+	}
+	if strings.EqualFold(lines[0], "COM0:") {
+		info.CommType = DOOR_COMM_LOCAL
+	} else {
+		info.CommType = DOOR_COMM_SERIAL
+	}
+	info.CommHandle = -1
+	info.Node = Val(lines[3])
+	info.UserName = lines[9]
+	if seconds, convErr := strconv.Atoi(lines[17]); convErr == nil {
+		info.TimeLeft = time.Duration(seconds) * time.Second
+	} else {
+		info.TimeLeft = time.Duration(Val(lines[18])) * time.Minute
+	}
+	if len(lines) >= 36 {
+		info.UserAlias = lines[35]
+	}
+	if len(lines) >= 46 {
+		info.UserRecord = Val(lines[45])
+	}
+	return
+}
+
+// DisplayName returns the name shown in high score lists.
+func (info *TDoorInfo) DisplayName() string {
+	if Length(info.UserAlias) != 0 {
+		return info.UserAlias
+	} else {
+		return info.UserName
+	}
+}
+
+// PlayerSaveFileName derives an 8-character save file name from a player's
+// name, as accepted by the PROMPT_ALPHANUM save prompt.
+func PlayerSaveFileName(name string) string {
+	var sb strings.Builder
+	for i := 0; i < len(name) && sb.Len() < 8; i++ {
+		c := UpCase(name[i])
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' {
+			sb.WriteByte(c)
+		}
+	}
+	if sb.Len() == 0 {
+		return "SAVED"
+	}
+	return sb.String()
+}
+
+func doorCsiKey(params string, final byte) (key TDoorKey) {
+	var mod int
+	fields := strings.Split(params, ";")
+	if len(fields) >= 2 {
+		mod = Val(fields[1])
+	}
+	key.Shift = mod > 1 && ((mod-1)&1) != 0
+	switch final {
+	case 'A':
+		key.Key = KEY_UP
+	case 'B':
+		key.Key = KEY_DOWN
+	case 'C':
+		key.Key = KEY_RIGHT
+	case 'D':
+		key.Key = KEY_LEFT
+	case 'H':
+		key.Key = KEY_HOME
+	case 'F':
+		key.Key = KEY_END
+	case 'Z':
+		key.Key = KEY_TAB
+	case 'P', 'Q', 'R', 'S':
+		key.Key = KEY_F1 + (final - 'P')
+	case '~':
+		switch Val(fields[0]) {
+		case 1, 7:
+			key.Key = KEY_HOME
+		case 2:
+			key.Key = KEY_INSERT
+		case 3:
+			key.Key = KEY_DELETE
+		case 4, 8:
+			key.Key = KEY_END
+		case 5:
+			key.Key = KEY_PAGE_UP
+		case 6:
+			key.Key = KEY_PAGE_DOWN
+		case 11, 12, 13, 14, 15:
+			key.Key = KEY_F1 + byte(Val(fields[0])-11)
+		case 17, 18, 19, 20, 21:
+			key.Key = KEY_F6 + byte(Val(fields[0])-17)
+		}
+	}
+	return
+}
+
+// Feed parses raw terminal input, stripping telnet negotiation and
+// translating ANSI escape sequences to ZZT key codes. Sequences split
+// across reads are carried over to the next one; a lone ESC at the end of
+// the data is held until then, or until Flush.
+func (p *DoorInputParser) Feed(data []byte) []TDoorKey {
+	keys := make([]TDoorKey, 0)
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch p.state {
+		case doorInputIac:
+			if c == TELNET_IAC {
+				p.state = doorInputNormal
+			} else if c >= TELNET_WILL && c <= TELNET_DONT {
+				p.state = doorInputIacOption
+			} else if c == TELNET_SB {
+				p.state = doorInputIacSub
+			} else {
+				p.state = doorInputNormal
+			}
+			continue
+		case doorInputIacOption:
+			p.state = doorInputNormal
+			continue
+		case doorInputIacSub:
+			if c == TELNET_IAC {
+				p.state = doorInputIacSubIac
+			}
+			continue
+		case doorInputIacSubIac:
+			if c == TELNET_SE {
+				p.state = doorInputNormal
+			} else {
+				p.state = doorInputIacSub
+			}
+			continue
+		case doorInputEsc:
+			p.seq = append(p.seq, c)
+			if len(p.seq) == 2 {
+				if c != '[' && c != 'O' {
+					keys = append(keys, TDoorKey{Key: KEY_ESCAPE})
+					p.state = doorInputNormal
+					p.seq = p.seq[:0]
+					i--
+				}
+			} else if p.seq[1] == 'O' || (c >= 0x40 && c <= 0x7E) {
+				key := doorCsiKey(string(p.seq[2:len(p.seq)-1]), c)
+				if key.Key != 0 {
+					keys = append(keys, key)
+				}
+				p.state = doorInputNormal
+				p.seq = p.seq[:0]
+			}
+			continue
+		}
+
+		if c == TELNET_IAC {
+			p.state = doorInputIac
+			continue
+		}
+		lastCR := p.lastCR
+		p.lastCR = c == '\r'
+		switch {
+		case c == KEY_ESCAPE:
+			p.state = doorInputEsc
+			p.seq = append(p.seq[:0], c)
+		case c == '\n' || c == '\x00':
+			if !lastCR && c == '\n' {
+				keys = append(keys, TDoorKey{Key: KEY_ENTER})
+			}
+		case c == '\x7f':
+			keys = append(keys, TDoorKey{Key: KEY_BACKSPACE})
+		case c < 0x80:
+			keys = append(keys, TDoorKey{Key: c})
+		}
+	}
+	return keys
+}
+
+// EscapePending returns true if the input ended with a lone ESC, which is
+// either the Escape key or the start of a sequence yet to arrive.
+func (p *DoorInputParser) EscapePending() bool {
+	return p.state == doorInputEsc && len(p.seq) == 1
+}
+
+// Flush takes a lone ESC, when nothing has followed it in time, to be the
+// Escape key.
+func (p *DoorInputParser) Flush() []TDoorKey {
+	if !p.EscapePending() {
+		return nil
+	}
+	p.state = doorInputNormal
+	p.seq = p.seq[:0]
+	return []TDoorKey{{Key: KEY_ESCAPE}}
+}
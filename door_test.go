@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeDropfile(t *testing.T, name string, lines []string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestDoorReadDropfileDoor32(t *testing.T) {
+	assert := assert.New(t)
+	filename := writeDropfile(t, "door32.sys", []string{
+		"2", "412", "38400", "Mystic 1.12", "17", "John Smith", "Sysop", "255", "45", "1", "3",
+	})
+	info, err := DoorReadDropfile(filename)
+	assert.Nil(err)
+	assert.Equal(DOOR_COMM_TELNET, info.CommType)
+	assert.Equal(412, info.CommHandle)
+	assert.Equal(17, info.UserRecord)
+	assert.Equal("Sysop", info.DisplayName())
+	assert.Equal(45*time.Minute, info.TimeLeft)
+	assert.Equal(3, info.Node)
+}
+
+func TestDoorReadDropfileDoorSys(t *testing.T) {
+	assert := assert.New(t)
+	lines := make([]string, 52)
+	lines[0] = "COM0:"
+	lines[3] = "2"
+	lines[9] = "Jane Doe"
+	lines[17] = "1800"
+	lines[18] = "30"
+	lines[35] = ""
+	lines[45] = "5"
+	info, err := DoorReadDropfile(writeDropfile(t, "DOOR.SYS", lines))
+	assert.Nil(err)
+	assert.Equal(DOOR_COMM_LOCAL, info.CommType)
+	assert.Equal("Jane Doe", info.DisplayName())
+	assert.Equal(30*time.Minute, info.TimeLeft)
+	assert.Equal(2, info.Node)
+	assert.Equal(5, info.UserRecord)
+
+	_, err = DoorReadDropfile(writeDropfile(t, "DOOR.SYS", lines[:10]))
+	assert.Equal(ErrDoorDropfileFormat, err)
+}
+
+func TestDoorInputParser(t *testing.T) {
+	assert := assert.New(t)
+	var p DoorInputParser
+	assert.Equal([]TDoorKey{{Key: KEY_UP}, {Key: KEY_LEFT, Shift: true}, {Key: 'a'}},
+		p.Feed([]byte("\x1b[A\x1b[1;2Da")))
+	assert.Equal([]TDoorKey{{Key: KEY_ENTER}, {Key: 'x'}},
+		p.Feed([]byte("\xff\xfb\x01\xff\xfa\x18\x00xterm\xff\xf0\r\nx")))
+	assert.Equal([]TDoorKey{{Key: KEY_F1}, {Key: KEY_PAGE_DOWN}},
+		p.Feed([]byte("\x1bOP\x1b[6~")))
+
+	// A lone ESC waits for what follows it, which may come in another read.
+	assert.Empty(p.Feed([]byte("\x1b")))
+	assert.True(p.EscapePending())
+	assert.Equal([]TDoorKey{{Key: KEY_UP}}, p.Feed([]byte("[A")))
+	assert.Empty(p.Feed([]byte("\x1b")))
+	assert.Equal([]TDoorKey{{Key: KEY_ESCAPE}, {Key: 'q'}}, p.Feed([]byte("q")))
+	assert.Empty(p.Feed([]byte("\x1b")))
+	assert.Equal([]TDoorKey{{Key: KEY_ESCAPE}}, p.Flush())
+	assert.False(p.EscapePending())
+	assert.Empty(p.Flush())
+}
+
+func TestPlayerSaveFileName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("JOHNSMIT", PlayerSaveFileName("John Smith"))
+	assert.Equal("SAVED", PlayerSaveFileName("!!!"))
+}
@@ -4,6 +4,7 @@ package main // unit: Editor
 
 import (
 	"bytes" // interface uses: GameVars, TxtWind
+	"io"
 
 	"github.com/OpenZoo/openzoo-go/format"
 )
@@ -54,6 +55,28 @@ func EditorLoop() {
 		copiedTile                 TTile
 		copiedX, copiedY           int16
 		cursorBlinker              int16
+		history                    *TEditorHistory
+		selecting                  bool
+		selectX, selectY           int16
+		clipboard                  *TEditorClipboard
+		fillMode, shapeMode        byte
+		boardsChanged              bool
+		statSort                   int
+		statFilter                 string
+		findText                   string
+		findMode, replaceMode      byte
+		replaceScope               byte
+		overlays                   TEditorOverlays
+		generator                  int
+		generateDensity            byte
+		transferSource             byte
+		imageFileName              string
+		imageDither                byte
+		imageText                  byte
+		transferTarget             byte
+		ansiFileName               string
+		recentColors               []byte
+		budget                     TEditorBudget
 	)
 	EditorDrawSidebar := func() {
 		var (
@@ -121,7 +144,9 @@ func EditorLoop() {
 	}
 
 	EditorUpdateSidebar := func() {
-		if drawMode == DrawingOn {
+		if selecting {
+			VideoWriteText(68, 24, 0x9E, "Selecting  ")
+		} else if drawMode == DrawingOn {
 			VideoWriteText(68, 24, 0x9E, "Drawing on ")
 		} else if drawMode == TextEntry {
 			VideoWriteText(68, 24, 0x9E, "Text entry ")
@@ -129,9 +154,17 @@ func EditorLoop() {
 			VideoWriteText(68, 24, 0x1E, "Drawing off")
 		}
 
-		VideoWriteText(72, 19, 0x1E, ColorNames[cursorColor-8-1])
+		VideoWriteText(72, 19, 0x1E, EditorColorLabel(byte(cursorColor)))
 		VideoWriteText(61+cursorPattern, 21, 0x1F, "\x1f")
-		VideoWriteText(61+cursorColor, 21, 0x1F, "\x1f")
+		// Colors other than the seven shown get a place of their own.
+		if cursorColor >= 9 && cursorColor <= 15 {
+			VideoWriteText(61+cursorColor, 21, 0x1F, "\x1f")
+		} else {
+			VideoWriteText(78, 21, 0x1F, "\x1f")
+		}
+		VideoWriteText(78, 22, byte(cursorColor), "\xfe")
+		budget.Update(history.Changes)
+		EditorDrawBudget(&budget)
 	}
 
 	EditorDrawRefresh := func() {
@@ -145,6 +178,64 @@ func EditorLoop() {
 		}
 	}
 
+	EditorSelectionRect := func() (x1, y1, x2, y2 int16) {
+		return editorNormalizeRect(selectX, selectY, cursorX, cursorY)
+	}
+
+	editorHighlight := func(x1, x2, y int16) {
+		var row []byte
+		VideoMove(x1-1, y-1, x2-x1+1, &row, false)
+		for i := 1; i < len(row); i += 2 {
+			row[i] ^= 0x7F
+		}
+		VideoMove(x1-1, y-1, x2-x1+1, &row, true)
+	}
+
+	EditorDrawSelection := func(highlight bool) {
+		var ix, iy int16
+		x1, y1, x2, y2 := EditorSelectionRect()
+		for iy = y1; iy <= y2; iy++ {
+			for ix = x1; ix <= x2; ix++ {
+				BoardDrawTile(ix, iy)
+			}
+			if highlight {
+				editorHighlight(x1, x2, iy)
+			}
+		}
+	}
+
+	EditorSelectionStart := func() {
+		selecting = true
+		selectX = cursorX
+		selectY = cursorY
+		drawMode = DrawingOff
+		EditorDrawSelection(true)
+	}
+
+	EditorSelectionEnd := func() {
+		if selecting {
+			EditorDrawSelection(false)
+			selecting = false
+		}
+	}
+
+	EditorDrawCursorTile := func() {
+		BoardDrawTile(cursorX, cursorY)
+		if selecting {
+			editorHighlight(cursorX, cursorX, cursorY)
+		}
+	}
+
+	EditorShowError := func(line1, line2 string) {
+		SidebarClearLine(3)
+		SidebarClearLine(4)
+		SidebarClearLine(5)
+		VideoWriteText(63, 4, 0x1E, line1)
+		VideoWriteText(63, 5, 0x1E, line2)
+		PauseOnError()
+		EditorDrawSidebar()
+	}
+
 	EditorSetAndCopyTile := func(x, y int16, element, color byte) {
 		Board.Tiles.Set(x, y, TTile{Element: element, Color: color})
 		copiedTile = Board.Tiles.Get(x, y)
@@ -154,12 +245,18 @@ func EditorLoop() {
 		EditorDrawTileAndNeighborsAt(int16(x), int16(y))
 	}
 
+	EditorWorldSave := func(prompt string) {
+		if EditorBudgetAllowsSave() {
+			GameWorldSave(prompt, &LoadedGameFileName, ".ZZT")
+		}
+	}
+
 	EditorAskSaveChanged := func() {
 		InputKeyPressed = '\x00'
 		if wasModified {
 			if SidebarPromptYesNo("Save first? ", true) {
 				if InputKeyPressed != KEY_ESCAPE {
-					GameWorldSave("Save world", &LoadedGameFileName, ".ZZT")
+					EditorWorldSave("Save world")
 				}
 			}
 		}
@@ -167,21 +264,34 @@ func EditorLoop() {
 	}
 
 	EditorPrepareModifyTile := func(x, y int16) (EditorPrepareModifyTile bool) {
+		history.Begin()
 		wasModified = true
 		EditorPrepareModifyTile = BoardPrepareTileForPlacement(x, y)
 		EditorDrawTileAndNeighborsAt(x, y)
 		return
 	}
 
-	EditorPrepareModifyStatAtCursor := func() (EditorPrepareModifyStatAtCursor bool) {
+	EditorPrepareModifyStatAt := func(x, y int16) (EditorPrepareModifyStatAt bool) {
 		if Board.Stats.Count < MAX_STAT {
-			EditorPrepareModifyStatAtCursor = EditorPrepareModifyTile(cursorX, cursorY)
+			EditorPrepareModifyStatAt = EditorPrepareModifyTile(x, y)
 		} else {
-			EditorPrepareModifyStatAtCursor = false
+			EditorPrepareModifyStatAt = false
 		}
 		return
 	}
 
+	EditorPrepareModifyStatAtCursor := func() bool {
+		return EditorPrepareModifyStatAt(cursorX, cursorY)
+	}
+
+	// EditorSetColor makes a color the current one, and the most recent.
+	EditorSetColor := func(color byte) {
+		cursorColor = int16(color)
+		recentColors = EditorRecentColor(recentColors, color)
+		VideoWriteText(72, 19, 0x1E, "       ")
+		VideoWriteText(69, 21, 0x1F, "          ")
+	}
+
 	EditorPlaceTile := func(x, y int16) {
 		Board.Tiles.With(x, y, func(tile *TTile) {
 			if cursorPattern <= EditorPatternCount {
@@ -190,7 +300,7 @@ func EditorLoop() {
 					tile.Color = byte(cursorColor)
 				}
 			} else if copiedHasStat {
-				if EditorPrepareModifyStatAtCursor() {
+				if EditorPrepareModifyStatAt(x, y) {
 					AddStat(x, y, copiedTile.Element, int16(copiedTile.Color), copiedStat.Cycle, copiedStat)
 				}
 			} else {
@@ -218,6 +328,7 @@ func EditorLoop() {
 			return
 		}
 
+		history.Begin()
 		state.Title = "Board Information"
 		state.DrawOpen()
 		state.LinePos = 1
@@ -291,6 +402,15 @@ func EditorLoop() {
 		state.DrawOpen()
 		state.Selectable = false
 		CopyStatDataToTextWindow(statId, &state)
+		// Labels are looked for once per change, not once per line drawn.
+		var labels []string
+		labelsEdits := -1
+		state.Highlight = func(line string) ([]byte, string) {
+			if labelsEdits != state.Edits {
+				labels, labelsEdits = OopCodeLabels(state.Lines), state.Edits
+			}
+			return OopCheckLine(line, labels)
+		}
 		stat.DataLen = 0
 		EditorOpenEditTextWindow(&state)
 		data := make([]byte, 0)
@@ -387,6 +507,7 @@ func EditorLoop() {
 			}
 		}
 
+		history.Begin()
 		stat := Board.Stats.At(statId)
 		SidebarClear()
 		element = Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element
@@ -410,12 +531,98 @@ func EditorLoop() {
 		}
 	}
 
+	// EditorImportImage draws a PNG image over a region of the board.
+	EditorImportImage := func(x1, y1, x2, y2 int16) {
+		SidebarPromptString("Import image", ".PNG", &imageFileName, PROMPT_ALPHANUM)
+		if InputKeyPressed == KEY_ESCAPE || Length(imageFileName) == 0 {
+			return
+		}
+		SidebarPromptChoice(true, 3, "Dither:", "None Ordered F-S", &imageDither)
+		if InputKeyPressed != KEY_ESCAPE {
+			SidebarPromptChoice(true, 6, "Elements:", "Walls Walls+text", &imageText)
+		}
+		if InputKeyPressed == KEY_ESCAPE {
+			return
+		}
+		img, err := EditorImageLoad(imageFileName + ".PNG")
+		if err == ErrImageInvalid {
+			EditorShowError("Not a PNG image!", "")
+			return
+		} else if err != nil {
+			DisplayIOError(err)
+			return
+		}
+		history.Begin()
+		wasModified = true
+		EditorImageImport(img, x1, y1, x2, y2, imageDither, imageText == 1)
+		EditorDrawRefresh()
+	}
+
+	// EditorImportAnsi draws an ANSI file over a region of the board.
+	EditorImportAnsi := func(x1, y1, x2, y2 int16) {
+		SidebarPromptString("Import ANSI", ".ANS", &ansiFileName, PROMPT_ALPHANUM)
+		if InputKeyPressed == KEY_ESCAPE || Length(ansiFileName) == 0 {
+			return
+		}
+		f, err := VfsOpen(ansiFileName + ".ANS")
+		if err != nil {
+			DisplayIOError(err)
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			DisplayIOError(err)
+			return
+		}
+		history.Begin()
+		wasModified = true
+		EditorAnsiImport(data, x1, y1, x2, y2)
+		EditorDrawRefresh()
+	}
+
+	// EditorExportAnsi writes the board to an ANSI file.
+	EditorExportAnsi := func() {
+		SidebarPromptString("Export ANSI", ".ANS", &ansiFileName, PROMPT_ALPHANUM)
+		if InputKeyPressed == KEY_ESCAPE || Length(ansiFileName) == 0 {
+			return
+		}
+		f, err := VfsCreate(ansiFileName + ".ANS")
+		if err != nil {
+			DisplayIOError(err)
+			return
+		}
+		defer f.Close()
+		data := EditorAnsiExport()
+		EditorDrawRefresh()
+		if _, err = f.Write(data); err != nil {
+			DisplayIOError(err)
+		}
+	}
+
 	EditorTransferBoard := func() {
 		var i byte
+		// Images and ANSI art are imported to the marked rectangle, if there is one.
+		x1, y1, x2, y2 := int16(1), int16(1), int16(BOARD_WIDTH), int16(BOARD_HEIGHT)
+		if selecting {
+			EditorSelectionEnd()
+			x1, y1, x2, y2 = EditorSelectionRect()
+		}
 		i = 1
 		SidebarPromptChoice(true, 3, "Transfer board:", "Import Export", &i)
+		if InputKeyPressed != KEY_ESCAPE && i == 0 {
+			SidebarPromptChoice(true, 6, "Import from:", "Board Image ANSI", &transferSource)
+		} else if InputKeyPressed != KEY_ESCAPE {
+			SidebarPromptChoice(true, 6, "Export to:", "Board ANSI", &transferTarget)
+		}
 		if InputKeyPressed != KEY_ESCAPE {
-			if i == 0 {
+			if i == 0 && transferSource == 1 {
+				EditorImportImage(x1, y1, x2, y2)
+			} else if i == 0 && transferSource == 2 {
+				EditorImportAnsi(x1, y1, x2, y2)
+			} else if i == 1 && transferTarget == 1 {
+				EditorExportAnsi()
+			} else if i == 0 {
 				SidebarPromptString("Import board", ".BRD", &SavedBoardFileName, PROMPT_ALPHANUM)
 				if InputKeyPressed != KEY_ESCAPE && Length(SavedBoardFileName) != 0 {
 					f, err := VfsOpen(SavedBoardFileName + ".BRD")
@@ -424,6 +631,7 @@ func EditorLoop() {
 						goto TransferEnd
 					}
 					defer f.Close()
+					history.Begin()
 					BoardClose()
 					var boardLen uint16
 					err = format.ReadPUShort(f, &boardLen)
@@ -465,32 +673,38 @@ func EditorLoop() {
 		EditorDrawSidebar()
 	}
 
-	EditorFloodFill := func(x, y int16, from TTile) {
-		var (
-			i              int16
-			tileAt         TTile
-			toFill, filled byte
-			xPosition      [256]int16
-			yPosition      [256]int16
-		)
-		toFill = 1
-		filled = 0
-		for toFill != filled {
-			tileAt = Board.Tiles.Get(x, y)
-			EditorPlaceTile(x, y)
-			if Board.Tiles.Get(x, y).Element != tileAt.Element || Board.Tiles.Get(x, y).Color != tileAt.Color {
-				for i = 0; i <= 3; i++ {
-					tile := Board.Tiles.Get(x+NeighborDeltaX[i], y+NeighborDeltaY[i])
-					if tile.Element == from.Element && (from.Element == 0 || tile.Color == from.Color) {
-						xPosition[toFill] = x + NeighborDeltaX[i]
-						yPosition[toFill] = y + NeighborDeltaY[i]
-						toFill++
-					}
+	// EditorPlaceTiles places the current pattern on every tile given, unless
+	// that would make for too many stats.
+	EditorPlaceTiles := func(points []TCoord) {
+		var statCount int16
+		if cursorPattern > EditorPatternCount && copiedHasStat {
+			statCount = Board.Stats.Count
+			for _, p := range points {
+				// Stats already there are replaced, and the player is kept.
+				if GetStatIdAt(p.X, p.Y) < 0 {
+					statCount++
 				}
 			}
-			filled++
-			x = xPosition[filled]
-			y = yPosition[filled]
+			if statCount > MAX_STAT {
+				EditorShowError("Too many stats", "for this shape!")
+				return
+			}
+		}
+		for _, p := range points {
+			EditorPlaceTile(p.X, p.Y)
+		}
+	}
+
+	// EditorJumpTo switches to a board and puts the cursor on x, y, unless x
+	// is zero.
+	EditorJumpTo := func(boardId, x, y int16) {
+		history.Begin()
+		if boardId != World.Info.CurrentBoard {
+			BoardChange(boardId)
+		}
+		if x > 0 {
+			cursorX = Max(1, Min(x, BOARD_WIDTH))
+			cursorY = Max(1, Min(y, BOARD_HEIGHT))
 		}
 	}
 
@@ -507,9 +721,15 @@ func EditorLoop() {
 	cursorPattern = 1
 	cursorColor = 0x0E
 	cursorBlinker = 0
+	history = NewEditorHistory(EditorUndoDepthArgument())
 	copiedHasStat = false
 	copiedTile.Element = 0
 	copiedTile.Color = 0x0F
+	fillMode = 1
+	shapeMode = SHAPE_LINE
+	findMode = 2
+	replaceMode = 2
+	generateDensity = 4
 	if World.Info.CurrentBoard != 0 {
 		BoardChange(World.Info.CurrentBoard)
 	}
@@ -518,29 +738,78 @@ func EditorLoop() {
 		EditorAppendBoard()
 	}
 	editorExitRequested = false
+	// The mouse points at tiles here; it does not play.
+	mouseEnabled := InputMouseEnabled
+	InputMouseEnabled = false
 	for {
+		if drawMode == DrawingOff && InputMouseButtons == 0 {
+			// A drawing, text entry or mouse stroke is undone as a whole.
+			history.End()
+		}
 		if drawMode == DrawingOn {
 			EditorPlaceTile(cursorX, cursorY)
 		}
 		Idle(IdleUntilFrame)
 		InputUpdate()
+		if InputKeyPressed == '\x00' && (InputMouseClicked != 0 || InputMouseMoved && InputMouseButtons != 0) &&
+			InputMouseCellX < BOARD_WIDTH && InputMouseCellY < BOARD_HEIGHT {
+			// The left button draws, or moves the cursor while typing text;
+			// the right one selects, and the middle one picks a color.
+			mouseX, mouseY := InputMouseCellX+1, InputMouseCellY+1
+			EditorDrawCursorTile()
+			if InputMouseClicked&MOUSE_BUTTON_RIGHT != 0 {
+				EditorSelectionEnd()
+				cursorX, cursorY = mouseX, mouseY
+				EditorSelectionStart()
+			} else if InputMouseButtons&MOUSE_BUTTON_RIGHT != 0 && selecting {
+				EditorDrawSelection(false)
+				cursorX, cursorY = mouseX, mouseY
+				EditorDrawSelection(true)
+			} else if InputMouseClicked&MOUSE_BUTTON_LEFT != 0 {
+				EditorSelectionEnd()
+				cursorX, cursorY = mouseX, mouseY
+				if drawMode != TextEntry {
+					EditorPlaceTile(cursorX, cursorY)
+				}
+			} else if InputMouseButtons&MOUSE_BUTTON_LEFT != 0 && !selecting && drawMode != TextEntry {
+				// Cells the mouse skipped over are drawn too.
+				EditorPlaceTiles(EditorShapeLine(cursorX, cursorY, mouseX, mouseY)[1:])
+				cursorX, cursorY = mouseX, mouseY
+			} else if InputMouseClicked&MOUSE_BUTTON_MIDDLE != 0 {
+				cursorX, cursorY = mouseX, mouseY
+				if color, ok := EditorTileColor(Board.Tiles.Get(cursorX, cursorY)); ok {
+					EditorSetColor(color)
+				}
+			}
+		}
 		if InputKeyPressed == '\x00' && InputDeltaX == 0 && InputDeltaY == 0 && !InputShiftPressed {
+			if overlays.Any() {
+				EditorDrawOverlays(&overlays, cursorX, cursorY)
+			}
 			if SoundHasTimeElapsed(&TickTimeCounter, 15) {
 				cursorBlinker = (cursorBlinker + 1) % 3
 			}
 			if cursorBlinker == 0 {
-				BoardDrawTile(cursorX, cursorY)
+				EditorDrawCursorTile()
 			} else {
 				VideoWriteText(cursorX-1, cursorY-1, 0x0F, "\xc5")
 			}
 			EditorUpdateSidebar()
 		} else {
-			BoardDrawTile(cursorX, cursorY)
+			EditorDrawCursorTile()
+		}
+		if selecting && InputKeyPressed != '\x00' && InputDeltaX == 0 && InputDeltaY == 0 {
+			// Any key but the ones acting on the selection ends it.
+			switch UpCase(InputKeyPressed) {
+			case 'M', 'D', 'R', 'G', 'T', KEY_CTRL_C, KEY_CTRL_X, KEY_F8, KEY_ESCAPE:
+			default:
+				EditorSelectionEnd()
+			}
 		}
 		if drawMode == TextEntry {
 			if InputKeyPressed >= ' ' && InputKeyPressed < '\x80' {
 				if EditorPrepareModifyTile(cursorX, cursorY) {
-					Board.Tiles.Set(cursorX, cursorY, TTile{Element: byte(cursorColor - 9 + E_TEXT_MIN), Color: byte(InputKeyPressed)})
+					Board.Tiles.Set(cursorX, cursorY, TTile{Element: EditorTextElement(byte(cursorColor)), Color: byte(InputKeyPressed)})
 					EditorDrawTileAndNeighborsAt(cursorX, cursorY)
 					InputDeltaX = 1
 					InputDeltaY = 0
@@ -554,7 +823,7 @@ func EditorLoop() {
 			}
 		}
 		tile := Board.Tiles.Pointer(cursorX, cursorY)
-		if InputShiftPressed || InputKeyPressed == ' ' {
+		if !selecting && (InputShiftPressed || InputKeyPressed == ' ') {
 			InputShiftAccepted = true
 			if tile.Element == 0 || ElementDefs[tile.Element].PlaceableOnTop && copiedHasStat && cursorPattern > EditorPatternCount || InputDeltaX != 0 || InputDeltaY != 0 {
 				EditorPlaceTile(cursorX, cursorY)
@@ -566,6 +835,9 @@ func EditorLoop() {
 			}
 		}
 		if InputDeltaX != 0 || InputDeltaY != 0 {
+			if selecting {
+				EditorDrawSelection(false)
+			}
 			cursorX += InputDeltaX
 			if cursorX < 1 {
 				cursorX = 1
@@ -580,6 +852,9 @@ func EditorLoop() {
 			if cursorY > BOARD_HEIGHT {
 				cursorY = BOARD_HEIGHT
 			}
+			if selecting {
+				EditorDrawSelection(true)
+			}
 			VideoWriteText(cursorX-1, cursorY-1, 0x0F, "\xc5")
 			if InputKeyPressed == '\x00' && InputJoystickEnabled {
 				Delay(70)
@@ -598,12 +873,14 @@ func EditorLoop() {
 			}
 		case 'C':
 			VideoWriteText(72, 19, 0x1E, "       ")
-			VideoWriteText(69, 21, 0x1F, "        ")
-			if cursorColor%0x10 != 0x0F {
-				cursorColor++
-			} else {
-				cursorColor = cursorColor/0x10*0x10 + 9
+			VideoWriteText(69, 21, 0x1F, "          ")
+			cursorColor = int16(EditorNextColor(byte(cursorColor)))
+		case 'K':
+			boardColor, hasBoardColor := EditorTileColor(Board.Tiles.Get(cursorX, cursorY))
+			if color, ok := EditorColorPicker(byte(cursorColor), recentColors, boardColor, hasBoardColor); ok {
+				EditorSetColor(color)
 			}
+			EditorDrawRefresh()
 		case 'L':
 			EditorAskSaveChanged()
 			if InputKeyPressed != KEY_ESCAPE && GameWorldLoad(".ZZT") {
@@ -624,17 +901,19 @@ func EditorLoop() {
 					}
 				}
 				wasModified = false
+				history.Clear()
 				EditorDrawRefresh()
 			}
 			EditorDrawSidebar()
 		case 'S':
-			GameWorldSave("Save world:", &LoadedGameFileName, ".ZZT")
+			EditorWorldSave("Save world:")
 			if InputKeyPressed != KEY_ESCAPE {
 				wasModified = false
 			}
 			EditorDrawSidebar()
 		case 'Z':
 			if SidebarPromptYesNo("Clear board? ", false) {
+				history.Begin()
 				for i = Board.Stats.Count; i >= 1; i-- {
 					RemoveStat(i)
 				}
@@ -649,15 +928,21 @@ func EditorLoop() {
 				if InputKeyPressed != KEY_ESCAPE {
 					WorldUnload()
 					WorldCreate()
+					history.Clear()
 					EditorDrawRefresh()
 					wasModified = false
 				}
 			}
 			EditorDrawSidebar()
 		case 'Q', KEY_ESCAPE:
-			editorExitRequested = true
+			if selecting && InputKeyPressed == KEY_ESCAPE {
+				EditorSelectionEnd()
+			} else {
+				editorExitRequested = true
+			}
 		case 'B':
-			i = EditorSelectBoard("Switch boards", World.Info.CurrentBoard, false)
+			history.Begin()
+			i, boardsChanged = EditorManageBoards("Switch boards", World.Info.CurrentBoard)
 			if InputKeyPressed != KEY_ESCAPE {
 				if int(i) >= len(World.BoardData) {
 					if SidebarPromptYesNo("Add new board? ", false) {
@@ -665,7 +950,88 @@ func EditorLoop() {
 					}
 				}
 				BoardChange(i)
-				EditorDrawRefresh()
+			}
+			if boardsChanged {
+				wasModified = true
+			}
+			EditorDrawRefresh()
+			EditorDrawSidebar()
+		case 'O':
+			if entry, ok := EditorBrowseStats(&statSort, &statFilter); ok {
+				EditorJumpTo(entry.Board, entry.X, entry.Y)
+			}
+			EditorDrawRefresh()
+			EditorDrawSidebar()
+		case 'E':
+			// Code of the stat under the cursor, in an external text editor
+			statId := GetStatIdAt(cursorX, cursorY)
+			if statId > 0 && Length(ElementDefs[Board.Tiles.Get(cursorX, cursorY).Element].ParamTextName) != 0 {
+				command := EditorExternalCommand()
+				if PlatformIsRemote() {
+					EditorShowError("Not available", "remotely!")
+				} else if Length(command) == 0 {
+					EditorShowError("No editor set;", "use /E:<command>")
+				} else {
+					history.Begin()
+					wasModified = true
+					if err := EditorEditStatExternal(statId, command); err == ErrEditorNoCommand {
+						EditorShowError("No editor set;", "use /E:<command>")
+					} else if err != nil {
+						EditorShowError("Editor failed:", Copy(err.Error(), 1, 16))
+					}
+					EditorDrawSidebar()
+				}
+			}
+		case 'F':
+			if match, ok := EditorBrowseTextMatches(&findText); ok {
+				EditorJumpTo(match.Board, match.X, match.Y)
+			}
+			EditorDrawRefresh()
+			EditorDrawSidebar()
+		case 'R':
+			// The tile under the cursor is replaced by the current pattern,
+			// within the selection if there is one.
+			tile := Board.Tiles.Get(cursorX, cursorY)
+			x1, y1, x2, y2 := int16(1), int16(1), int16(BOARD_WIDTH), int16(BOARD_HEIGHT)
+			marked := selecting
+			world := false
+			if marked {
+				EditorSelectionEnd()
+				x1, y1, x2, y2 = EditorSelectionRect()
+			}
+			SidebarPromptChoice(true, 3, "Find by:", "Element Color Both", &findMode)
+			if InputKeyPressed != KEY_ESCAPE {
+				SidebarPromptChoice(true, 6, "Replace:", "Element Color Both", &replaceMode)
+			}
+			if InputKeyPressed != KEY_ESCAPE && !marked {
+				SidebarPromptChoice(true, 9, "Replace in:", "Board World", &replaceScope)
+				world = replaceScope == 1
+			}
+			if InputKeyPressed != KEY_ESCAPE {
+				query := TEditorTileQuery{Element: tile.Element, Color: tile.Color, ByElement: findMode != 1, ByColor: findMode != 0}
+				count := EditorCountTiles(query, world, x1, y1, x2, y2)
+				for i = 6; i <= 11; i++ {
+					SidebarClearLine(i)
+				}
+				VideoWriteText(63, 7, 0x1E, "Matches: "+Str(count))
+				if count > 0 && SidebarPromptYesNo("Replace? ", false) {
+					replacement, stat := copiedTile, (*TStat)(nil)
+					if cursorPattern <= EditorPatternCount {
+						replacement = TTile{Element: EditorPatterns[cursorPattern-1], Color: byte(cursorColor)}
+					} else if copiedHasStat {
+						stat = &copiedStat
+					}
+					history.Begin()
+					wasModified = true
+					_, skipped := EditorReplaceTiles(query, world, x1, y1, x2, y2,
+						replacement, stat, replaceMode != 1, replaceMode != 0)
+					EditorDrawRefresh()
+					if skipped > 0 {
+						EditorShowError(Str(skipped)+" tile(s) not", "replaced!")
+					}
+				} else if count == 0 {
+					EditorShowError("No tiles match!", "")
+				}
 			}
 			EditorDrawSidebar()
 		case '?':
@@ -700,16 +1066,10 @@ func EditorLoop() {
 					}
 					VideoWriteText(61, i, byte(i%2<<6+0x30), " "+Chr(ElementDefs[iElem].EditorShortcut)+" ")
 					VideoWriteText(65, i, 0x1F, ElementDefs[iElem].Name)
-					if ElementDefs[iElem].Color == COLOR_CHOICE_ON_BLACK {
-						elemMenuColor = cursorColor%0x10 + 0x10
-					} else if ElementDefs[iElem].Color == COLOR_WHITE_ON_CHOICE {
-						elemMenuColor = cursorColor*0x10 - 0x71
-					} else if ElementDefs[iElem].Color == COLOR_CHOICE_ON_CHOICE {
-						elemMenuColor = (cursorColor-8)*0x11 + 8
-					} else if int16(ElementDefs[iElem].Color)&0x70 == 0x00 {
-						elemMenuColor = int16(ElementDefs[iElem].Color)%0x10 + 0x10
-					} else {
-						elemMenuColor = int16(ElementDefs[iElem].Color)
+					// Colors on black are shown on the sidebar's blue.
+					elemMenuColor = int16(EditorElementColor(ElementDefs[iElem].Color, byte(cursorColor)))
+					if elemMenuColor&0x70 == 0x00 {
+						elemMenuColor = elemMenuColor%0x10 + 0x10
 					}
 
 					VideoWriteText(78, i, byte(elemMenuColor), Chr(ElementDefs[iElem].Character))
@@ -724,15 +1084,7 @@ func EditorLoop() {
 							MoveStat(0, cursorX, cursorY)
 						}
 					} else {
-						if ElementDefs[iElem].Color == COLOR_CHOICE_ON_BLACK {
-							elemMenuColor = cursorColor
-						} else if ElementDefs[iElem].Color == COLOR_WHITE_ON_CHOICE {
-							elemMenuColor = cursorColor*0x10 - 0x71
-						} else if ElementDefs[iElem].Color == COLOR_CHOICE_ON_CHOICE {
-							elemMenuColor = (cursorColor-8)*0x11 + 8
-						} else {
-							elemMenuColor = int16(ElementDefs[iElem].Color)
-						}
+						elemMenuColor = int16(EditorElementColor(ElementDefs[iElem].Color, byte(cursorColor)))
 
 						if ElementDefs[iElem].Cycle == -1 {
 							if EditorPrepareModifyTile(cursorX, cursorY) {
@@ -771,10 +1123,123 @@ func EditorLoop() {
 			} else {
 				drawMode = DrawingOff
 			}
+		case KEY_F5:
+			drawMode = DrawingOff
+			EditorTestPlay(cursorX, cursorY)
+			EditorDrawRefresh()
+		case KEY_F6:
+			history.Begin()
+			statId, changed := EditorEditTickOrder(&overlays.StatNumbers)
+			if changed {
+				wasModified = true
+			}
+			if statId >= 0 {
+				cursorX = Max(1, Min(int16(Board.Stats.At(statId).X), BOARD_WIDTH))
+				cursorY = Max(1, Min(int16(Board.Stats.At(statId).Y), BOARD_HEIGHT))
+			}
+			EditorDrawRefresh()
+		case KEY_F7:
+			// Raw fields of the stat under the cursor
+			statId := GetStatIdAt(cursorX, cursorY)
+			if statId < 0 {
+				EditorShowError("No stat here!", "")
+			} else {
+				history.Begin()
+				if EditorInspectStat(statId) {
+					wasModified = true
+				}
+				EditorDrawRefresh()
+			}
+		case KEY_F8:
+			// The marked region, or else the tile under the cursor, can be
+			// added; the entry picked becomes the clipboard and is pasted.
+			picked := EditorLibrary(func() *TEditorClipboard {
+				if selecting {
+					return EditorClipboardCopy(EditorSelectionRect())
+				}
+				return EditorClipboardCopy(cursorX, cursorY, cursorX, cursorY)
+			})
+			EditorSelectionEnd()
+			if picked != nil {
+				clipboard = picked
+				history.Begin()
+				if EditorClipboardPaste(clipboard, cursorX, cursorY) {
+					wasModified = true
+				} else {
+					EditorShowError("Too many stats", "to paste here!")
+				}
+			}
+			EditorDrawRefresh()
+		case KEY_F9:
+			history.Begin()
+			boardId, pos, changed := EditorWorldMap()
+			if changed {
+				wasModified = true
+			}
+			if boardId >= 0 {
+				EditorJumpTo(boardId, pos.X, pos.Y)
+			}
+			EditorDrawRefresh()
+		case KEY_F10:
+			EditorOverlayMenu(&overlays)
+			EditorDrawRefresh()
 		case 'H':
 			TextWindowDisplayFile("editor.hlp", "World editor help")
 		case 'X':
-			EditorFloodFill(cursorX, cursorY, Board.Tiles.Get(cursorX, cursorY))
+			SidebarPromptChoice(true, 3, "Fill by:", "Element Color", &fillMode)
+			if InputKeyPressed != KEY_ESCAPE {
+				EditorPlaceTiles(EditorFloodRegion(cursorX, cursorY, fillMode == 1))
+			}
+			EditorDrawSidebar()
+		case 'G':
+			x1, y1, x2, y2 := int16(1), int16(1), int16(BOARD_WIDTH), int16(BOARD_HEIGHT)
+			if selecting {
+				EditorSelectionEnd()
+				x1, y1, x2, y2 = EditorSelectionRect()
+			}
+			if EditorGenerateMenu(&generator, &generateDensity) {
+				// Walls are made of the current pattern, or of normal walls
+				// if it is a copied tile with a stat.
+				material := TTile{Element: E_NORMAL, Color: byte(cursorColor)}
+				if cursorPattern <= EditorPatternCount {
+					material.Element = EditorPatterns[cursorPattern-1]
+				} else if !copiedHasStat {
+					material = copiedTile
+				}
+				history.Begin()
+				wasModified = true
+				skipped := EditorGenerators[generator].Generate(x1, y1, x2, y2, int16(generateDensity)+1, material, byte(cursorColor))
+				EditorDrawRefresh()
+				if skipped > 0 {
+					EditorShowError(Str(skipped)+" creature(s) not", "placed!")
+				}
+			}
+			EditorDrawSidebar()
+		case 'D':
+			if !selecting {
+				EditorSelectionStart()
+			} else {
+				EditorSelectionEnd()
+				SidebarPromptChoice(true, 3, "Draw shape:", "Line Box Oval", &shapeMode)
+				if InputKeyPressed != KEY_ESCAPE {
+					filled := false
+					if shapeMode != SHAPE_LINE {
+						filled = SidebarPromptYesNo("Filled? ", false)
+					}
+					if InputKeyPressed != KEY_ESCAPE {
+						x1, y1, x2, y2 := selectX, selectY, cursorX, cursorY
+						switch shapeMode {
+						case SHAPE_LINE:
+							EditorPlaceTiles(EditorShapeLine(x1, y1, x2, y2))
+						case SHAPE_BOX:
+							EditorPlaceTiles(EditorShapeBox(x1, y1, x2, y2, filled))
+						case SHAPE_OVAL:
+							EditorPlaceTiles(EditorShapeOval(x1, y1, x2, y2, filled))
+						}
+					}
+				}
+				EditorDrawSidebar()
+			}
 		case '!':
 			EditorEditHelpFile()
 			EditorDrawSidebar()
@@ -791,6 +1256,48 @@ func EditorLoop() {
 		case 'I':
 			EditorEditBoardInfo()
 			TransitionDrawToBoard()
+		case 'M':
+			if selecting {
+				EditorSelectionEnd()
+			} else {
+				EditorSelectionStart()
+			}
+		case KEY_CTRL_C, KEY_CTRL_X:
+			// Without a selection, the tile under the cursor is copied.
+			if !selecting {
+				selectX = cursorX
+				selectY = cursorY
+			}
+			EditorSelectionEnd()
+			clipboard = EditorClipboardCopy(EditorSelectionRect())
+			if InputKeyPressed == KEY_CTRL_X {
+				history.Begin()
+				wasModified = true
+				EditorClearRegion(EditorSelectionRect())
+			}
+		case KEY_CTRL_V:
+			if clipboard != nil {
+				history.Begin()
+				if EditorClipboardPaste(clipboard, cursorX, cursorY) {
+					wasModified = true
+				} else {
+					EditorShowError("Too many stats", "to paste here!")
+				}
+			}
+		case KEY_CTRL_Z, KEY_CTRL_Y:
+			if drawMode == DrawingOn {
+				drawMode = DrawingOff
+			}
+			var changed bool
+			if InputKeyPressed == KEY_CTRL_Z {
+				changed = history.Undo()
+			} else {
+				changed = history.Redo()
+			}
+			if changed {
+				wasModified = true
+				EditorDrawRefresh()
+			}
 		}
 		if editorExitRequested {
 			EditorAskSaveChanged()
@@ -803,6 +1310,7 @@ func EditorLoop() {
 			break
 		}
 	}
+	InputMouseEnabled = mouseEnabled
 	InputKeyPressed = '\x00'
 	InitElementsGame()
 }
@@ -859,7 +1367,7 @@ func EditorGetBoardName(boardId int, titleScreenIsNone bool) (EditorGetBoardName
 	return
 }
 
-func EditorSelectBoard(title string, currentBoard int16, titleScreenIsNone bool) (EditorSelectBoard int16) {
+func editorSelectBoard(title string, currentBoard int16, titleScreenIsNone bool, exitKeys string) (boardId int16) {
 	var (
 		textWindow TTextWindowState
 	)
@@ -867,6 +1375,7 @@ func EditorSelectBoard(title string, currentBoard int16, titleScreenIsNone bool)
 	textWindow.Title = title
 	textWindow.LinePos = int(currentBoard + 1)
 	textWindow.Selectable = true
+	textWindow.ExitKeys = exitKeys
 	for i := 0; i < len(World.BoardData); i++ {
 		textWindow.Append(EditorGetBoardName(i, titleScreenIsNone))
 	}
@@ -874,10 +1383,17 @@ func EditorSelectBoard(title string, currentBoard int16, titleScreenIsNone bool)
 	textWindow.DrawOpen()
 	textWindow.Select(false, false)
 	textWindow.DrawClose()
-	if InputKeyPressed == KEY_ESCAPE {
-		EditorSelectBoard = 0
+	if TextWindowRejected {
+		// Select clears the key; callers check for it.
+		InputKeyPressed = KEY_ESCAPE
+		boardId = 0
 	} else {
-		EditorSelectBoard = int16(textWindow.LinePos - 1)
+		boardId = int16(textWindow.LinePos - 1)
 	}
 	return
 }
+
+func EditorSelectBoard(title string, currentBoard int16, titleScreenIsNone bool) (EditorSelectBoard int16) {
+	EditorSelectBoard = editorSelectBoard(title, currentBoard, titleScreenIsNone, "")
+	return
+}
@@ -0,0 +1,324 @@
+//go:build editor
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ANSI art - boards to and from .ANS files
+//
+// Boards are written as CP437 characters with SGR color codes, a row per
+// line, followed by a SAUCE record describing them. ANSI files are read
+// into an 80x25 screen, which is then drawn over the board in walls and
+// text of the nearest colors.
+
+const (
+	sauceRecordSize   = 128
+	sauceDataTypeChar = 1
+	sauceFileTypeAnsi = 1
+)
+
+// TSauce holds the SAUCE metadata of an ANSI file; strings are cut to the
+// length of their fields.
+type TSauce struct {
+	Title  string // 35 characters
+	Author string // 20 characters
+	Group  string // 20 characters
+	Date   string // CCYYMMDD
+	Width  uint16
+	Height uint16
+}
+
+// sauceField pads or cuts a string to a fixed-width field.
+func sauceField(s string, width int, pad byte) []byte {
+	field := bytes.Repeat([]byte{pad}, width)
+	copy(field, s)
+	return field
+}
+
+// SauceAppend ends ANSI data with an end-of-file mark and a SAUCE record.
+func SauceAppend(data []byte, sauce TSauce) []byte {
+	var b bytes.Buffer
+	fileSize := len(data)
+	b.Write(data)
+	b.WriteByte(0x1A)
+	b.WriteString("SAUCE00")
+	b.Write(sauceField(sauce.Title, 35, ' '))
+	b.Write(sauceField(sauce.Author, 20, ' '))
+	b.Write(sauceField(sauce.Group, 20, ' '))
+	b.Write(sauceField(sauce.Date, 8, ' '))
+	binary.Write(&b, binary.LittleEndian, uint32(fileSize))
+	b.WriteByte(sauceDataTypeChar)
+	b.WriteByte(sauceFileTypeAnsi)
+	binary.Write(&b, binary.LittleEndian, [4]uint16{sauce.Width, sauce.Height, 0, 0})
+	b.WriteByte(0) // comment lines
+	b.WriteByte(0) // flags
+	b.Write(sauceField("IBM VGA", 22, 0))
+	return b.Bytes()
+}
+
+// SauceSplit separates ANSI data from its SAUCE record and comments, if it
+// has them, and cuts it at the end-of-file mark.
+func SauceSplit(data []byte) (body []byte, sauce *TSauce) {
+	body = data
+	if n := len(data); n >= sauceRecordSize && string(data[n-sauceRecordSize:n-sauceRecordSize+5]) == "SAUCE" {
+		record := data[n-sauceRecordSize:]
+		field := func(from, to int) string {
+			return strings.TrimRight(string(record[from:to]), " \x00")
+		}
+		sauce = &TSauce{
+			Title:  field(7, 42),
+			Author: field(42, 62),
+			Group:  field(62, 82),
+			Date:   field(82, 90),
+		}
+		if record[94] == sauceDataTypeChar {
+			sauce.Width = binary.LittleEndian.Uint16(record[96:])
+			sauce.Height = binary.LittleEndian.Uint16(record[98:])
+		}
+		body = data[:n-sauceRecordSize]
+		comments := int(record[104])*64 + 5
+		if comments > 5 && len(body) >= comments && string(body[len(body)-comments:len(body)-comments+5]) == "COMNT" {
+			body = body[:len(body)-comments]
+		}
+	}
+	if i := bytes.IndexByte(body, 0x1A); i >= 0 {
+		body = body[:i]
+	}
+	return
+}
+
+// AnsiEncodeScreen writes the top left width x height cells of a screen as
+// ANSI art, a row per line.
+func AnsiEncodeScreen(screen *TTextBuffer, width, height int) []byte {
+	var sb strings.Builder
+	for y := 0; y < height; y++ {
+		attr := -1
+		for x := 0; x < width; x++ {
+			if int(screen[y][x*2+1]) != attr {
+				attr = int(screen[y][x*2+1])
+				sb.WriteString(AnsiAttrSGR(byte(attr)))
+			}
+			sb.WriteString(AnsiCharCP437(screen[y][x*2]))
+		}
+		// Colors are reset before each line break, so that the background
+		// does not run on to the end of the line.
+		sb.WriteString("\x1b[0m\r\n")
+	}
+	return []byte(sb.String())
+}
+
+// AnsiDecodeScreen plays ANSI art onto an 80x25 screen; lines wrap at width
+// columns, or 80 if it is zero. Whatever falls outside the screen is lost.
+func AnsiDecodeScreen(data []byte, width int) (screen TTextBuffer) {
+	if width <= 0 {
+		width = 80
+	}
+	var x, y, savedX, savedY int
+	var fg, bg byte = 7, 0
+	var bold, blink, reverse bool
+	clear := func(y, x1, x2 int) {
+		for ; x1 < x2; x1++ {
+			screen[y][x1*2] = ' '
+			screen[y][x1*2+1] = 0x07
+		}
+	}
+	for iy := range screen {
+		clear(iy, 0, 80)
+	}
+	attr := func() byte {
+		f, b := fg, bg
+		if reverse {
+			f, b = b, f
+		}
+		if bold {
+			f |= 0x08
+		}
+		if blink {
+			b |= 0x08
+		}
+		return b<<4 | f
+	}
+
+	for i := 0; i < len(data); i++ {
+		switch ch := data[i]; {
+		case ch == '\r':
+			x = 0
+		case ch == '\n':
+			x = 0
+			y++
+		case ch == '\t':
+			x = (x/8 + 1) * 8
+		case ch == 0x1B && i+1 < len(data) && data[i+1] == '[':
+			// A control sequence: parameters, then the command letter.
+			i += 2
+			start := i
+			for i < len(data) && (data[i] < 0x40 || data[i] > 0x7E) {
+				i++
+			}
+			if i >= len(data) {
+				break
+			}
+			var params []int
+			for _, p := range strings.Split(strings.TrimLeft(string(data[start:i]), "?="), ";") {
+				v, _ := strconv.Atoi(p)
+				params = append(params, v)
+			}
+			param := func(n, def int) int {
+				if n < len(params) && params[n] > 0 {
+					return params[n]
+				}
+				return def
+			}
+			switch data[i] {
+			case 'A':
+				y = Max(0, y-param(0, 1))
+			case 'B':
+				y += param(0, 1)
+			case 'C':
+				x = Min(width-1, x+param(0, 1))
+			case 'D':
+				x = Max(0, x-param(0, 1))
+			case 'H', 'f':
+				y = param(0, 1) - 1
+				x = Min(width-1, param(1, 1)-1)
+			case 'J':
+				if param(0, 0) == 2 {
+					for iy := range screen {
+						clear(iy, 0, 80)
+					}
+					x, y = 0, 0
+				}
+			case 'K':
+				if y < len(screen) && x < 80 {
+					clear(y, x, 80)
+				}
+			case 's':
+				savedX, savedY = x, y
+			case 'u':
+				x, y = savedX, savedY
+			case 'm':
+				for _, p := range params {
+					switch {
+					case p == 0:
+						fg, bg = 7, 0
+						bold, blink, reverse = false, false, false
+					case p == 1:
+						bold = true
+					case p == 5 || p == 6:
+						blink = true
+					case p == 7:
+						reverse = true
+					case p == 22:
+						bold = false
+					case p == 25:
+						blink = false
+					case p == 27:
+						reverse = false
+					case p >= 30 && p <= 37:
+						fg = ansiColorMap[p-30]
+					case p == 39:
+						fg = 7
+					case p >= 40 && p <= 47:
+						bg = ansiColorMap[p-40]
+					case p == 49:
+						bg = 0
+					case p >= 90 && p <= 97:
+						fg = ansiColorMap[p-90] | 0x08
+					case p >= 100 && p <= 107:
+						bg = ansiColorMap[p-100] | 0x08
+					}
+				}
+			}
+		default:
+			// A full line wraps at the next character, not at once, so
+			// that a line break right after it does not leave a gap.
+			if x >= width {
+				x = 0
+				y++
+			}
+			if y < len(screen) && x < 80 {
+				screen[y][x*2] = ch
+				screen[y][x*2+1] = attr()
+			}
+			x++
+		}
+	}
+	return
+}
+
+// AnsiCellTile returns the tile closest to a cell of ANSI art: blank cells
+// and full blocks are solid walls of their color, shaded blocks are normal
+// and breakable walls, and any other character is text on the background
+// of its element, white if there is none.
+func AnsiCellTile(ch, attr byte) TTile {
+	fg, bg := attr&0x0F, attr>>4&0x07
+	if ch == 0 || ch == ' ' || ch == 0xFF || fg == bg {
+		ch, fg = ElementDefs[E_SOLID].Character, bg
+	}
+	switch {
+	case ch == ElementDefs[E_SOLID].Character && fg == 0:
+		return TTile{Element: E_EMPTY}
+	case ch == ElementDefs[E_SOLID].Character:
+		return TTile{Element: E_SOLID, Color: fg}
+	case ch == ElementDefs[E_NORMAL].Character:
+		return TTile{Element: E_NORMAL, Color: bg<<4 | fg}
+	case ch == ElementDefs[E_BREAKABLE].Character:
+		return TTile{Element: E_BREAKABLE, Color: bg<<4 | fg}
+	case bg >= 1 && bg <= 6:
+		return TTile{Element: E_TEXT_MIN + bg - 1, Color: ch}
+	default:
+		return TTile{Element: E_TEXT_WHITE, Color: ch}
+	}
+}
+
+// EditorAnsiImport draws ANSI art over a region of the board, from its top
+// left corner; the part of the art which does not fit is cut off. Tiles
+// with stats are left as they are.
+func EditorAnsiImport(data []byte, x1, y1, x2, y2 int16) {
+	var ix, iy int16
+	body, sauce := SauceSplit(data)
+	width := 0
+	if sauce != nil {
+		width = int(sauce.Width)
+	}
+	screen := AnsiDecodeScreen(body, width)
+	for iy = y1; iy <= y2 && iy-y1 < 25; iy++ {
+		for ix = x1; ix <= x2 && ix-x1 < 80; ix++ {
+			if GetStatIdAt(ix, iy) < 0 {
+				row := screen[iy-y1][(ix-x1)*2:]
+				Board.Tiles.Set(ix, iy, AnsiCellTile(row[0], row[1]))
+			}
+		}
+	}
+}
+
+// EditorAnsiExport returns the board as ANSI art, as the game draws it
+// with the lights on.
+func EditorAnsiExport() []byte {
+	var ix, iy int16
+	var screen TTextBuffer
+	InitElementsGame()
+	ForceDarknessOff = true
+	for iy = 1; iy <= BOARD_HEIGHT; iy++ {
+		for ix = 1; ix <= BOARD_WIDTH; ix++ {
+			BoardDrawTile(ix, iy)
+		}
+		row := make([]byte, BOARD_WIDTH*2)
+		VideoMove(0, iy-1, BOARD_WIDTH, &row, false)
+		copy(screen[iy-1][:], row)
+	}
+	InitElementsEditor()
+	return SauceAppend(AnsiEncodeScreen(&screen, BOARD_WIDTH, BOARD_HEIGHT), TSauce{
+		Title:  Board.Name,
+		Group:  World.Info.Name,
+		Date:   time.Now().Format("20060102"),
+		Width:  BOARD_WIDTH,
+		Height: BOARD_HEIGHT,
+	})
+}
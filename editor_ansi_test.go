@@ -0,0 +1,71 @@
+//go:build editor
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnsiDecodeScreen(t *testing.T) {
+	assert := assert.New(t)
+	screen := AnsiDecodeScreen([]byte("\x1b[1;31mA\x1b[0;44mB\r\n\x1b[3;5H\x1b[33;5mC\x1b[sX\x1b[uD"), 0)
+	assert.Equal([]byte{'A', 0x0C, 'B', 0x17}, screen[0][:4])
+	assert.Equal([]byte{' ', 0x07}, screen[1][:2])
+	assert.Equal([]byte{'C', 0x96, 'D', 0x96}, screen[2][8:12])
+
+	// Lines wrap at the width given.
+	screen = AnsiDecodeScreen([]byte("abc"), 2)
+	assert.Equal(byte('b'), screen[0][2])
+	assert.Equal(byte('c'), screen[1][0])
+}
+
+func TestSauce(t *testing.T) {
+	assert := assert.New(t)
+	data := SauceAppend([]byte("art"), TSauce{Title: "Town", Date: "20261019", Width: 60, Height: 25})
+	assert.Len(data, 4+sauceRecordSize)
+	assert.Equal(byte(0x1A), data[3])
+	assert.Equal("SAUCE00", string(data[4:11]))
+
+	body, sauce := SauceSplit(data)
+	assert.Equal([]byte("art"), body)
+	assert.Equal(&TSauce{Title: "Town", Date: "20261019", Width: 60, Height: 25}, sauce)
+	body, sauce = SauceSplit([]byte("art\x1ajunk"))
+	assert.Equal([]byte("art"), body)
+	assert.Nil(sauce)
+}
+
+func TestEditorAnsiRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	InitElementsEditor()
+	defer InitElementsGame()
+
+	assert.Equal(TTile{Element: E_EMPTY}, AnsiCellTile(' ', 0x07))
+	assert.Equal(TTile{Element: E_SOLID, Color: 0x04}, AnsiCellTile(' ', 0x4F))
+	assert.Equal(TTile{Element: E_SOLID, Color: 0x0E}, AnsiCellTile('\xdb', 0x1E))
+	assert.Equal(TTile{Element: E_NORMAL, Color: 0x1E}, AnsiCellTile('\xb2', 0x9E))
+	assert.Equal(TTile{Element: E_TEXT_GREEN, Color: 'x'}, AnsiCellTile('x', 0x2F))
+	assert.Equal(TTile{Element: E_TEXT_WHITE, Color: 'x'}, AnsiCellTile('x', 0x7C))
+
+	Board.Name = "Round trip"
+	Board.Tiles.Set(10, 3, TTile{Element: E_SOLID, Color: 0x0C})
+	Board.Tiles.Set(11, 3, TTile{Element: E_TEXT_CYAN, Color: 'Z'})
+	Board.Tiles.Set(12, 3, TTile{Element: E_BREAKABLE, Color: 0x3A})
+	data := EditorAnsiExport()
+	assert.True(bytes.Contains(data, []byte("SAUCE00Round trip")))
+	_, sauce := SauceSplit(data)
+	assert.Equal(uint16(BOARD_WIDTH), sauce.Width)
+
+	player := Board.Tiles.Get(int16(Board.Stats.At(0).X), int16(Board.Stats.At(0).Y))
+	WorldCreate()
+	EditorAnsiImport(data, 1, 1, BOARD_WIDTH, BOARD_HEIGHT)
+	assert.Equal(TTile{Element: E_SOLID, Color: 0x0C}, Board.Tiles.Get(10, 3))
+	assert.Equal(TTile{Element: E_TEXT_CYAN, Color: 'Z'}, Board.Tiles.Get(11, 3))
+	assert.Equal(TTile{Element: E_BREAKABLE, Color: 0x3A}, Board.Tiles.Get(12, 3))
+	assert.Equal(TTile{Element: E_EMPTY}, Board.Tiles.Get(13, 3))
+	assert.Equal(player, Board.Tiles.Get(int16(Board.Stats.At(0).X), int16(Board.Stats.At(0).Y)))
+}
@@ -0,0 +1,176 @@
+//go:build editor
+
+package main
+
+import "strings"
+
+// Editor board management - duplicating, deleting and reordering boards
+//
+// Boards refer to each other by index, through their neighbor links and the
+// passages on them, so every change in order rewrites those references.
+// Neighbor links to a deleted board are cleared, and passages to it lead to
+// the title screen, board 0, instead.
+
+// EditorRearrangeBoards replaces the world's boards with the ones listed in
+// order, by their current index; a board may be listed twice. References
+// are rewritten to the first place of the board they point to, or to 0 if
+// it is left out. It returns the new index of the current board, or -1 if
+// it was left out.
+func EditorRearrangeBoards(order []int16) (currentBoard int16) {
+	BoardClose()
+	mapping := make([]int16, len(World.BoardData))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		mapping[order[i]] = int16(i)
+	}
+	remap := func(boardId byte) byte {
+		if int(boardId) >= len(mapping) {
+			return boardId
+		} else if mapping[boardId] < 0 {
+			return 0
+		}
+		return byte(mapping[boardId])
+	}
+
+	boardData := make([][]byte, len(order))
+	for i, boardId := range order {
+		boardData[i] = World.BoardData[boardId]
+	}
+	currentBoard = mapping[World.Info.CurrentBoard]
+	World.BoardData = boardData
+
+	for i := int16(0); i < int16(len(World.BoardData)); i++ {
+		BoardOpen(i)
+		for j := 0; j < 4; j++ {
+			Board.Info.NeighborBoards[j] = remap(Board.Info.NeighborBoards[j])
+		}
+		for j := int16(1); j <= Board.Stats.Count; j++ {
+			stat := Board.Stats.At(j)
+			if Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element == E_PASSAGE {
+				stat.P3 = remap(stat.P3)
+			}
+		}
+		BoardClose()
+	}
+	EditorStatSettings[E_PASSAGE].P3 = remap(EditorStatSettings[E_PASSAGE].P3)
+	if currentBoard >= 0 {
+		BoardOpen(currentBoard)
+	}
+	return
+}
+
+// EditorBoardReferences counts the neighbor links and passages on other
+// boards which lead to boardId.
+func EditorBoardReferences(boardId int16) (count int) {
+	currentBoard := World.Info.CurrentBoard
+	BoardClose()
+	for i := int16(0); i < int16(len(World.BoardData)); i++ {
+		if i == boardId {
+			continue
+		}
+		BoardOpen(i)
+		for j := 0; j < 4; j++ {
+			if int16(Board.Info.NeighborBoards[j]) == boardId {
+				count++
+			}
+		}
+		for j := int16(1); j <= Board.Stats.Count; j++ {
+			stat := Board.Stats.At(j)
+			if Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element == E_PASSAGE && int16(stat.P3) == boardId {
+				count++
+			}
+		}
+	}
+	BoardOpen(currentBoard)
+	return
+}
+
+func editorBoardOrder(count int) []int16 {
+	order := make([]int16, count)
+	for i := range order {
+		order[i] = int16(i)
+	}
+	return order
+}
+
+// EditorDuplicateBoard places a copy of a board right after it.
+func EditorDuplicateBoard(boardId int16) {
+	order := editorBoardOrder(len(World.BoardData))
+	order = append(order[:boardId+1], append([]int16{boardId}, order[boardId+1:]...)...)
+	EditorRearrangeBoards(order)
+}
+
+// EditorDeleteBoard removes a board. If it was the current one, the board
+// before it becomes current.
+func EditorDeleteBoard(boardId int16) {
+	order := editorBoardOrder(len(World.BoardData))
+	order = append(order[:boardId], order[boardId+1:]...)
+	if EditorRearrangeBoards(order) < 0 {
+		BoardOpen(boardId - 1)
+	}
+}
+
+// EditorMoveBoard swaps a board with the one delta places away.
+func EditorMoveBoard(boardId, delta int16) {
+	order := editorBoardOrder(len(World.BoardData))
+	order[boardId], order[boardId+delta] = order[boardId+delta], order[boardId]
+	EditorRearrangeBoards(order)
+}
+
+// EditorManageBoards is the board selector with the board management keys
+// enabled. The title screen can not be moved or deleted.
+func EditorManageBoards(title string, currentBoard int16) (boardId int16, changed bool) {
+	const actionKeys = "DX\xd3-+="
+	for {
+		boardId = editorSelectBoard(title+" (D:Dup Del:Delete -+:Move)", currentBoard, false, actionKeys)
+		if TextWindowRejected || strings.IndexByte(actionKeys, UpCase(InputKeyPressed)) < 0 {
+			return
+		}
+		currentBoard = boardId
+		if int(boardId) >= len(World.BoardData) {
+			// "Add new board" can not be acted upon.
+			continue
+		}
+		switch UpCase(InputKeyPressed) {
+		case 'D':
+			if len(World.BoardData) <= MAX_BOARD {
+				EditorDuplicateBoard(boardId)
+				currentBoard = boardId + 1
+				changed = true
+			}
+		case 'X', KEY_DELETE:
+			if boardId > 0 && len(World.BoardData) > 2 {
+				references := EditorBoardReferences(boardId)
+				if references > 0 {
+					VideoWriteText(63, 7, 0x1E, Str(references)+" link(s) to it")
+					VideoWriteText(63, 8, 0x1E, "will be cleared;")
+					VideoWriteText(63, 9, 0x1E, "passages will go")
+					VideoWriteText(63, 10, 0x1E, "to the title!")
+				}
+				if SidebarPromptYesNo("Delete board? ", false) {
+					EditorDeleteBoard(boardId)
+					currentBoard = boardId - 1
+					changed = true
+				}
+				SidebarClearLine(7)
+				SidebarClearLine(8)
+				SidebarClearLine(9)
+				SidebarClearLine(10)
+			}
+		case '-':
+			if boardId > 1 {
+				EditorMoveBoard(boardId, -1)
+				currentBoard = boardId - 1
+				changed = true
+			}
+		case '+', '=':
+			if boardId > 0 && int(boardId) < len(World.BoardData)-1 {
+				EditorMoveBoard(boardId, 1)
+				currentBoard = boardId + 1
+				changed = true
+			}
+		}
+	}
+}
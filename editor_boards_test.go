@@ -0,0 +1,67 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorBoardManagement(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	// Boards 1-3, each linking north to the next and with a passage to
+	// board 2.
+	for i := int16(1); i <= 3; i++ {
+		BoardClose()
+		World.BoardData = append(World.BoardData, nil)
+		World.Info.CurrentBoard = i
+		BoardCreate()
+		Board.Name = "Board " + Str(i)
+		Board.Info.NeighborBoards[0] = byte((i + 1) % 4)
+		AddStat(5, 5, E_PASSAGE, 0x1F, 0, StatTemplateDefault)
+		Board.Stats.At(1).P3 = 2
+	}
+	BoardClose()
+	BoardOpen(1)
+
+	neighbor := func(boardId int16) byte {
+		BoardOpen(boardId)
+		return Board.Info.NeighborBoards[0]
+	}
+	passage := func(boardId int16) byte {
+		BoardOpen(boardId)
+		return Board.Stats.At(1).P3
+	}
+
+	assert.Equal(3, EditorBoardReferences(2))
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+
+	EditorMoveBoard(2, -1)
+	assert.Equal(int16(2), World.Info.CurrentBoard)
+	assert.Equal("Board 2", EditorGetBoardName(1, false))
+	assert.Equal(byte(1), neighbor(2))
+	assert.Equal(byte(3), neighbor(1))
+	assert.Equal(byte(1), passage(3))
+
+	BoardOpen(1)
+	EditorDuplicateBoard(1)
+	assert.Equal(5, len(World.BoardData))
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+	assert.Equal("Board 2", EditorGetBoardName(2, false))
+	assert.Equal(byte(4), neighbor(2))
+	assert.Equal(byte(1), neighbor(3))
+	assert.Equal(byte(1), passage(4))
+
+	BoardOpen(1)
+	EditorDeleteBoard(1)
+	assert.Equal(4, len(World.BoardData))
+	assert.Equal(int16(0), World.Info.CurrentBoard)
+	// Links to a deleted board are cleared, and passages go to the title.
+	assert.Equal(byte(0), neighbor(2))
+	assert.Equal(byte(0), passage(3))
+	assert.Equal(byte(3), neighbor(1))
+}
@@ -0,0 +1,128 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/OpenZoo/openzoo-go/format"
+)
+
+// Editor budget meter - how close the current board is to what DOS ZZT can
+// load, and refusing to save worlds which it could not.
+
+// EDITOR_BOARD_MAX_SIZE is the largest board, as stored in the world file,
+// that DOS ZZT reads without crashing.
+const EDITOR_BOARD_MAX_SIZE = 20000
+
+type editorByteCounter int
+
+func (c *editorByteCounter) Write(p []byte) (int, error) {
+	*c += editorByteCounter(len(p))
+	return len(p), nil
+}
+
+// EditorBoardSize returns the size of the current board as it would be saved.
+func EditorBoardSize() int {
+	var c editorByteCounter
+	format.BoardSerialize(&Board, &c)
+	return int(c)
+}
+
+// EditorWorldSize returns the size of the world file as it would be saved,
+// given the size of the current board.
+func EditorWorldSize(boardSize int) (size int) {
+	size = format.WORLD_FILE_HEADER_SIZE
+	for i := range World.BoardData {
+		if int16(i) == World.Info.CurrentBoard {
+			size += 2 + boardSize
+		} else {
+			size += 2 + len(World.BoardData[i])
+		}
+	}
+	return
+}
+
+// editorBudgetColor is yellow while value is well within max, red once it
+// comes close, and blinking red past it.
+func editorBudgetColor(value, max int) byte {
+	if value > max {
+		return 0x9C
+	} else if value*10 >= max*9 {
+		return 0x1C
+	}
+	return 0x1E
+}
+
+// TEditorBudget keeps the sizes shown on the sidebar, as serializing the
+// board to measure it is too slow to do on every frame.
+type TEditorBudget struct {
+	BoardSize, WorldSize int
+	changes              int
+	measured             bool
+}
+
+// Update measures the board and world again if changes, a count of the
+// changes made so far, has moved on since the last time.
+func (b *TEditorBudget) Update(changes int) {
+	if !b.measured || changes != b.changes {
+		b.BoardSize = EditorBoardSize()
+		b.WorldSize = EditorWorldSize(b.BoardSize)
+		b.changes = changes
+		b.measured = true
+	}
+}
+
+// EditorDrawBudget shows the stat count, board size and world size on the
+// sidebar.
+func EditorDrawBudget(b *TEditorBudget) {
+	VideoWriteText(61, 3, 0x1F, " Stats:")
+	VideoWriteText(68, 3, editorBudgetColor(int(Board.Stats.Count), MAX_STAT),
+		fmt.Sprintf("%3d/%-7d", Board.Stats.Count, MAX_STAT))
+	VideoWriteText(61, 6, 0x1F, " Board:")
+	VideoWriteText(68, 6, editorBudgetColor(b.BoardSize, EDITOR_BOARD_MAX_SIZE), fmt.Sprintf("%5d bytes", b.BoardSize))
+	VideoWriteText(61, 9, 0x1F, " World:")
+	VideoWriteText(68, 9, 0x1E, fmt.Sprintf("%5d KB    ", (b.WorldSize+1023)/1024))
+}
+
+// EditorBudgetProblems lists the boards which DOS ZZT could not load.
+func EditorBudgetProblems() (problems []string) {
+	editorForEachBoard(true, func(boardId int16) {
+		name := Board.Name
+		if Length(name) == 0 {
+			name = "Untitled"
+		}
+		if size := len(World.BoardData[boardId]); size > EDITOR_BOARD_MAX_SIZE {
+			problems = append(problems, fmt.Sprintf("%3d %s: %d bytes", boardId, name, size))
+		}
+		if Board.Stats.Count > MAX_STAT {
+			problems = append(problems, fmt.Sprintf("%3d %s: %d stats", boardId, name, Board.Stats.Count+1))
+		}
+	})
+	return
+}
+
+// EditorBudgetAllowsSave lists the boards which are over the limits, if
+// any, and returns false, with the key pressed set to Escape, if so.
+func EditorBudgetAllowsSave() bool {
+	var textWindow TTextWindowState
+	problems := EditorBudgetProblems()
+	if len(problems) == 0 {
+		return true
+	}
+	textWindow.Init()
+	textWindow.Title = "World not saved"
+	textWindow.Append("DOS ZZT can not load boards over")
+	textWindow.Append(Str(EDITOR_BOARD_MAX_SIZE) + " bytes, or with over " + Str(MAX_STAT+1) + " stats:")
+	textWindow.Append("")
+	for _, problem := range problems {
+		textWindow.Append(problem)
+	}
+	textWindow.Append("")
+	textWindow.Append("Make them smaller, then save again.")
+	textWindow.DrawOpen()
+	textWindow.Select(false, false)
+	textWindow.DrawClose()
+	InputKeyPressed = KEY_ESCAPE
+	return false
+}
@@ -0,0 +1,58 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorBudget(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	BoardClose()
+	World.BoardData = append(World.BoardData, World.BoardData[0])
+
+	size := EditorBoardSize()
+	assert.Equal(len(World.BoardData[0]), size)
+	assert.Equal(512+2*(2+size), EditorWorldSize(size))
+	assert.Empty(EditorBudgetProblems())
+
+	// A long program pushes the board over the limit, on any board.
+	data := make([]byte, EDITOR_BOARD_MAX_SIZE)
+	AddStat(5, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	Board.Stats.At(1).Data = &data
+	Board.Stats.At(1).DataLen = int16(len(data))
+	assert.Greater(EditorBoardSize(), EDITOR_BOARD_MAX_SIZE)
+	BoardChange(1)
+	problems := EditorBudgetProblems()
+	if assert.Len(problems, 1) {
+		assert.Contains(problems[0], "  0 Title screen: ")
+	}
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+	assert.Equal(0x9C, int(editorBudgetColor(EDITOR_BOARD_MAX_SIZE+1, EDITOR_BOARD_MAX_SIZE)))
+	assert.Equal(0x1C, int(editorBudgetColor(MAX_STAT-10, MAX_STAT)))
+	assert.Equal(0x1E, int(editorBudgetColor(MAX_STAT-20, MAX_STAT)))
+}
+
+func TestEditorBudgetUpdate(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	history := NewEditorHistory(0)
+	var budget TEditorBudget
+	budget.Update(history.Changes)
+	size := budget.BoardSize
+	assert.Equal(EditorBoardSize(), size)
+
+	// The board is only measured again once it may have been changed.
+	AddStat(5, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	budget.Update(history.Changes)
+	assert.Equal(size, budget.BoardSize)
+	history.Begin()
+	budget.Update(history.Changes)
+	assert.Greater(budget.BoardSize, size)
+	assert.Equal(EditorWorldSize(budget.BoardSize), budget.WorldSize)
+}
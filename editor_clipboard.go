@@ -0,0 +1,192 @@
+//go:build editor
+
+package main
+
+// Editor clipboard - rectangular board regions, with their stats
+//
+// Stats are kept in board order, with coordinates relative to the region's
+// top-left corner and Leader/Follower as indexes into the clipboard's stats.
+// Stats sharing their code (#BIND) keep sharing it in every copy made.
+
+type TEditorClipboard struct {
+	Width, Height int16
+	Tiles         []TTile
+	Stats         []TStat
+}
+
+func (c *TEditorClipboard) Tile(x, y int16) TTile {
+	return c.Tiles[y*c.Width+x]
+}
+
+// editorCloneStatData gives stats sharing data in the source a shared copy
+// of it in the destination.
+func editorCloneStatData(stat *TStat, clones map[*[]byte]*[]byte) {
+	if stat.Data == nil {
+		return
+	}
+	clone, ok := clones[stat.Data]
+	if !ok {
+		data := make([]byte, len(*stat.Data))
+		copy(data, *stat.Data)
+		clone = &data
+		clones[stat.Data] = clone
+	}
+	stat.Data = clone
+}
+
+func editorNormalizeRect(x1, y1, x2, y2 int16) (int16, int16, int16, int16) {
+	return Min(x1, x2), Min(y1, y2), Max(x1, x2), Max(y1, y2)
+}
+
+// EditorClipboardCopy copies a region of the board. The player is left out;
+// the tile under it is copied instead.
+func EditorClipboardCopy(x1, y1, x2, y2 int16) *TEditorClipboard {
+	var ix, iy, i int16
+	x1, y1, x2, y2 = editorNormalizeRect(x1, y1, x2, y2)
+	c := &TEditorClipboard{Width: x2 - x1 + 1, Height: y2 - y1 + 1}
+	c.Tiles = make([]TTile, c.Width*c.Height)
+	player := Board.Stats.At(0)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if ix == int16(player.X) && iy == int16(player.Y) {
+				c.Tiles[(iy-y1)*c.Width+(ix-x1)] = player.Under
+			} else {
+				c.Tiles[(iy-y1)*c.Width+(ix-x1)] = Board.Tiles.Get(ix, iy)
+			}
+		}
+	}
+
+	clipIds := make(map[int16]int16)
+	clones := make(map[*[]byte]*[]byte)
+	for i = 1; i <= Board.Stats.Count; i++ {
+		stat := *Board.Stats.At(i)
+		if int16(stat.X) >= x1 && int16(stat.X) <= x2 && int16(stat.Y) >= y1 && int16(stat.Y) <= y2 {
+			stat.X -= byte(x1)
+			stat.Y -= byte(y1)
+			editorCloneStatData(&stat, clones)
+			clipIds[i] = int16(len(c.Stats))
+			c.Stats = append(c.Stats, stat)
+		}
+	}
+	for i = 0; i < int16(len(c.Stats)); i++ {
+		stat := &c.Stats[i]
+		if id, ok := clipIds[stat.Leader]; ok {
+			stat.Leader = id
+		} else {
+			stat.Leader = -1
+		}
+		if id, ok := clipIds[stat.Follower]; ok {
+			stat.Follower = id
+		} else {
+			stat.Follower = -1
+		}
+	}
+	return c
+}
+
+// EditorClearRegion empties a region of the board, removing its stats. The
+// player stays where it is.
+func EditorClearRegion(x1, y1, x2, y2 int16) {
+	var ix, iy, statId int16
+	x1, y1, x2, y2 = editorNormalizeRect(x1, y1, x2, y2)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			statId = GetStatIdAt(ix, iy)
+			if statId == 0 {
+				continue
+			}
+			if statId > 0 {
+				RemoveStat(statId)
+			}
+			Board.Tiles.Set(ix, iy, TTile{Element: E_EMPTY, Color: 0})
+			BoardDrawTile(ix, iy)
+		}
+	}
+}
+
+// editorPasteVisible returns true if the clipboard position lies on the
+// board when pasted at x, y, and not on the player.
+func editorPasteVisible(x, y int16) bool {
+	player := Board.Stats.At(0)
+	return x >= 1 && x <= BOARD_WIDTH && y >= 1 && y <= BOARD_HEIGHT &&
+		(x != int16(player.X) || y != int16(player.Y))
+}
+
+// EditorClipboardPasteStatCount returns how many stats the board would have
+// after pasting c at x, y.
+func EditorClipboardPasteStatCount(c *TEditorClipboard, x, y int16) (count int16) {
+	var i int16
+	count = Board.Stats.Count
+	for i = 1; i <= Board.Stats.Count; i++ {
+		stat := Board.Stats.At(i)
+		if int16(stat.X) >= x && int16(stat.X) < x+c.Width && int16(stat.Y) >= y && int16(stat.Y) < y+c.Height &&
+			editorPasteVisible(int16(stat.X), int16(stat.Y)) {
+			count--
+		}
+	}
+	for i = 0; i < int16(len(c.Stats)); i++ {
+		if editorPasteVisible(x+int16(c.Stats[i].X), y+int16(c.Stats[i].Y)) {
+			count++
+		}
+	}
+	return
+}
+
+// EditorClipboardPaste pastes c with its top-left corner at x, y. Anything
+// falling off the board or onto the player is left out. It returns false,
+// changing nothing, if the board would end up with too many stats.
+func EditorClipboardPaste(c *TEditorClipboard, x, y int16) bool {
+	var ix, iy, i, statId int16
+	if EditorClipboardPasteStatCount(c, x, y) > MAX_STAT {
+		return false
+	}
+	for iy = 0; iy < c.Height; iy++ {
+		for ix = 0; ix < c.Width; ix++ {
+			if !editorPasteVisible(x+ix, y+iy) {
+				continue
+			}
+			statId = GetStatIdAt(x+ix, y+iy)
+			if statId > 0 {
+				RemoveStat(statId)
+			}
+			Board.Tiles.Set(x+ix, y+iy, c.Tile(ix, iy))
+		}
+	}
+
+	boardIds := make([]int16, len(c.Stats))
+	clones := make(map[*[]byte]*[]byte)
+	for i = 0; i < int16(len(c.Stats)); i++ {
+		boardIds[i] = -1
+		stat := c.Stats[i]
+		stat.X += byte(x)
+		stat.Y += byte(y)
+		if !editorPasteVisible(int16(stat.X), int16(stat.Y)) {
+			continue
+		}
+		editorCloneStatData(&stat, clones)
+		Board.Stats.Count++
+		*Board.Stats.At(Board.Stats.Count) = stat
+		boardIds[i] = Board.Stats.Count
+	}
+	for i = 0; i < int16(len(c.Stats)); i++ {
+		if boardIds[i] < 0 {
+			continue
+		}
+		stat := Board.Stats.At(boardIds[i])
+		if stat.Leader >= 0 {
+			stat.Leader = boardIds[stat.Leader]
+		}
+		if stat.Follower >= 0 {
+			stat.Follower = boardIds[stat.Follower]
+		}
+	}
+
+	for iy = 0; iy < c.Height; iy++ {
+		for ix = 0; ix < c.Width; ix++ {
+			if x+ix >= 1 && x+ix <= BOARD_WIDTH && y+iy >= 1 && y+iy <= BOARD_HEIGHT {
+				BoardDrawTile(x+ix, y+iy)
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorClipboard(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	code := []byte("@bound\r#end\r")
+	Board.Tiles.Set(5, 5, TTile{Element: E_WATER, Color: 0x9F})
+	AddStat(5, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	AddStat(6, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	AddStat(20, 20, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	for i := int16(1); i <= 3; i++ {
+		Board.Stats.At(i).Data = &code
+		Board.Stats.At(i).DataLen = int16(len(code))
+	}
+	AddStat(5, 6, E_CENTIPEDE_HEAD, 0x0F, 2, StatTemplateDefault)
+	AddStat(6, 6, E_CENTIPEDE_SEGMENT, 0x0F, 2, StatTemplateDefault)
+	Board.Stats.At(4).Follower = 5
+	Board.Stats.At(5).Leader = 4
+
+	c := EditorClipboardCopy(6, 6, 5, 5)
+	assert.Equal(int16(2), c.Width)
+	assert.Equal(int16(2), c.Height)
+	assert.Equal(4, len(c.Stats))
+	assert.Equal(byte(E_OBJECT), c.Tile(1, 0).Element)
+	assert.True(c.Stats[0].Data == c.Stats[1].Data, "bound stats share their code")
+	assert.False(c.Stats[0].Data == &code)
+	assert.Equal(int16(3), c.Stats[2].Follower)
+	assert.Equal(int16(2), c.Stats[3].Leader)
+
+	assert.True(EditorClipboardPaste(c, 30, 10))
+	assert.Equal(int16(9), Board.Stats.Count)
+	assert.Equal(byte(E_OBJECT), Board.Tiles.Get(31, 10).Element)
+	assert.Equal(byte(E_WATER), Board.Stats.At(6).Under.Element)
+	assert.True(Board.Stats.At(6).Data == Board.Stats.At(7).Data)
+	assert.False(Board.Stats.At(6).Data == c.Stats[0].Data)
+	assert.Equal(int16(9), Board.Stats.At(8).Follower)
+	assert.Equal(int16(8), Board.Stats.At(9).Leader)
+
+	// Pasting over the same spot replaces the stats there.
+	assert.True(EditorClipboardPaste(c, 30, 10))
+	assert.Equal(int16(9), Board.Stats.Count)
+
+	// The player is never overwritten.
+	player := Board.Stats.At(0)
+	assert.True(EditorClipboardPaste(c, int16(player.X)-1, int16(player.Y)))
+	assert.Equal(int16(12), Board.Stats.Count)
+	assert.Equal(byte(E_PLAYER), Board.Tiles.Get(int16(player.X), int16(player.Y)).Element)
+
+	EditorClearRegion(30, 10, 31, 11)
+	assert.Equal(int16(8), Board.Stats.Count)
+	assert.Equal(byte(E_EMPTY), Board.Tiles.Get(30, 10).Element)
+
+	for Board.Stats.Count < MAX_STAT-1 {
+		AddStat(40, 20, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	}
+	assert.False(EditorClipboardPaste(c, 50, 15))
+	assert.Equal(int16(MAX_STAT-1), Board.Stats.Count)
+	assert.Equal(byte(E_EMPTY), Board.Tiles.Get(50, 15).Element)
+}
@@ -0,0 +1,200 @@
+//go:build editor
+
+package main
+
+import "fmt"
+
+// Color picker - any of the 256 text-mode attributes, blink included
+//
+// The editor's color is a full attribute: foreground in the low nibble,
+// background in the next three bits and blink in the top one. Elements
+// placed in the editor's color take all of it; those drawn white on it, or
+// on a darker shade of it, take the shade of its foreground, as ZZT does
+// with its seven.
+
+const (
+	EDITOR_RECENT_COLORS = 8
+	colorPickerX         = 6
+	colorPickerY         = 2
+)
+
+var editorColorNames = [16]string{
+	"Black", "Dark blue", "Dark green", "Dark cyan", "Dark red", "Dark purple", "Brown", "Gray",
+	"Dark gray", "Blue", "Green", "Cyan", "Red", "Purple", "Yellow", "White",
+}
+
+// EditorColorName describes an attribute, like "Yellow on Dark blue".
+func EditorColorName(color byte) (name string) {
+	name = editorColorNames[color&0x0F]
+	if color&0x70 != 0 {
+		name += " on " + editorColorNames[color>>4&0x07]
+	}
+	if color&0x80 != 0 {
+		name += ", blinking"
+	}
+	return
+}
+
+// EditorColorLabel is the short name of an attribute for the sidebar: the
+// name of one of ZZT's seven colors, or else its value in hex.
+func EditorColorLabel(color byte) string {
+	if color >= 9 && color <= 15 {
+		return ColorNames[color-9]
+	}
+	return fmt.Sprintf("$%02X", color)
+}
+
+// EditorNextColor returns the color after the given one among ZZT's seven,
+// starting again from the first for any other attribute.
+func EditorNextColor(color byte) byte {
+	if color < 9 || color >= 15 {
+		return 9
+	}
+	return color + 1
+}
+
+// EditorElementColor returns the color to place an element in, given its
+// default color and the editor's.
+func EditorElementColor(defColor, color byte) byte {
+	switch defColor {
+	case COLOR_CHOICE_ON_BLACK:
+		return color
+	case COLOR_WHITE_ON_CHOICE:
+		return color&0x80 | color&0x07<<4 | 0x0F
+	case COLOR_CHOICE_ON_CHOICE:
+		return color&0x80 | color&0x07<<4 | color&0x07 | 0x08
+	default:
+		return defColor
+	}
+}
+
+// EditorTileColor returns the editor color which would place a tile as it
+// is: the reverse of EditorElementColor, and of EditorTextElement for text.
+// Empty tiles have no color to pick.
+func EditorTileColor(tile TTile) (color byte, ok bool) {
+	switch {
+	case tile.Element == E_EMPTY:
+		return 0, false
+	case tile.Element == E_TEXT_WHITE:
+		return 0x0F, true
+	case tile.Element >= E_TEXT_MIN:
+		return tile.Element - E_TEXT_MIN + 9, true
+	}
+	switch ElementDefs[tile.Element].Color {
+	case COLOR_WHITE_ON_CHOICE:
+		return tile.Color&0x80 | tile.Color>>4&0x07 | 0x08, true
+	case COLOR_CHOICE_ON_CHOICE:
+		return tile.Color&0x80 | tile.Color&0x0F, true
+	default:
+		return tile.Color, true
+	}
+}
+
+// EditorTextElement returns the text element typed in a color: the one of
+// its background if it has one, or else of its foreground, or white.
+func EditorTextElement(color byte) byte {
+	if bg := color >> 4 & 0x07; bg >= 1 && bg <= 6 {
+		return E_TEXT_MIN + bg - 1
+	}
+	if fg := color & 0x07; fg >= 1 && fg <= 6 {
+		return E_TEXT_MIN + fg - 1
+	}
+	return E_TEXT_WHITE
+}
+
+// EditorRecentColor puts a color first in the list of recent ones.
+func EditorRecentColor(recent []byte, color byte) []byte {
+	list := []byte{color}
+	for _, c := range recent {
+		if c != color && len(list) < EDITOR_RECENT_COLORS {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+func editorDrawColorPicker(color byte, recent []byte) {
+	var ix, iy int16
+	for iy = 0; iy < BOARD_HEIGHT; iy++ {
+		VideoWriteText(0, iy, 0x00, fmt.Sprintf("%60s", ""))
+	}
+	// Backgrounds from 8 up are those with the blink bit set.
+	for iy = 0; iy < 16; iy++ {
+		for ix = 0; ix < 16; ix++ {
+			c := byte(iy<<4 | ix)
+			cell := " \xfe "
+			if c == color {
+				cell = "[\xfe]"
+			}
+			VideoWriteText(colorPickerX+ix*3, colorPickerY+iy, c, cell)
+		}
+	}
+	VideoWriteText(colorPickerX, colorPickerY+17, 0x0F, fmt.Sprintf("%-48s", EditorColorName(color)))
+	VideoWriteText(colorPickerX, colorPickerY+19, 0x07, "Recent:")
+	for i, c := range recent {
+		VideoWriteText(colorPickerX+8+int16(i)*5, colorPickerY+19, 0x0F, Str(int16(i+1)))
+		VideoWriteText(colorPickerX+10+int16(i)*5, colorPickerY+19, c, "\xfe")
+	}
+}
+
+func editorDrawColorPickerSidebar(boardColor bool) {
+	SidebarClear()
+	VideoWriteText(64, 3, 0x1F, "Pick a color")
+	VideoWriteText(61, 5, 0x70, " Arrows ")
+	VideoWriteText(69, 5, 0x1F, " Move")
+	VideoWriteText(61, 6, 0x30, " B ")
+	VideoWriteText(64, 6, 0x1F, " Blink")
+	VideoWriteText(61, 7, 0x70, " 1-8 ")
+	VideoWriteText(66, 7, 0x1F, " Recent")
+	if boardColor {
+		VideoWriteText(61, 8, 0x30, " P ")
+		VideoWriteText(64, 8, 0x1F, " From board")
+	}
+	VideoWriteText(61, 10, 0x70, " Enter ")
+	VideoWriteText(68, 10, 0x1F, " Pick")
+}
+
+// EditorColorPicker shows every attribute, for one to be picked with the
+// arrows or the mouse, starting from color. P picks boardColor, the color
+// of the tile under the cursor, if there is one. It returns false if
+// cancelled.
+func EditorColorPicker(color byte, recent []byte, boardColor byte, hasBoardColor bool) (byte, bool) {
+	editorDrawColorPickerSidebar(hasBoardColor)
+	redraw := true
+	for {
+		if redraw {
+			editorDrawColorPicker(color, recent)
+			redraw = false
+		}
+		Idle(IdleUntilFrame)
+		InputUpdate()
+		if InputMouseClicked&MOUSE_BUTTON_LEFT != 0 {
+			x := (InputMouseCellX - colorPickerX) / 3
+			y := InputMouseCellY - colorPickerY
+			if InputMouseCellX >= colorPickerX && x < 16 && y >= 0 && y < 16 {
+				return byte(y<<4 | x), true
+			}
+		}
+		if InputDeltaX != 0 || InputDeltaY != 0 {
+			x := (int16(color&0x0F) + InputDeltaX + 16) % 16
+			y := (int16(color>>4) + InputDeltaY + 16) % 16
+			color = byte(y<<4 | x)
+			redraw = true
+			continue
+		}
+		switch key := UpCase(InputKeyPressed); {
+		case key == 'B':
+			color ^= 0x80
+			redraw = true
+		case key >= '1' && int(key-'1') < len(recent):
+			color = recent[key-'1']
+			redraw = true
+		case key == 'P' && hasBoardColor:
+			return boardColor, true
+		case key == KEY_ENTER || key == ' ':
+			return color, true
+		case key == KEY_ESCAPE:
+			return color, false
+		}
+	}
+}
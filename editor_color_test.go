@@ -0,0 +1,69 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorElementColor(t *testing.T) {
+	assert := assert.New(t)
+	InitElementsEditor()
+	defer InitElementsGame()
+
+	// ZZT's seven colors come out as they always have.
+	for c := byte(9); c <= 15; c++ {
+		assert.Equal(c, EditorElementColor(COLOR_CHOICE_ON_BLACK, c))
+		assert.Equal(c*0x10-0x71, EditorElementColor(COLOR_WHITE_ON_CHOICE, c))
+		assert.Equal((c-8)*0x11+8, EditorElementColor(COLOR_CHOICE_ON_CHOICE, c))
+		assert.Equal(E_TEXT_MIN+c-9, EditorTextElement(c))
+	}
+	assert.Equal(byte(0x9E), EditorElementColor(COLOR_CHOICE_ON_BLACK, 0x9E))
+	assert.Equal(byte(0xCF), EditorElementColor(COLOR_WHITE_ON_CHOICE, 0xB4))
+	assert.Equal(byte(0x0A), EditorElementColor(0x0A, 0x9E))
+	assert.Equal(byte(E_TEXT_RED), EditorTextElement(0x4F))
+	assert.Equal(byte(E_TEXT_WHITE), EditorTextElement(0x70))
+
+	// Picking a color off a tile gives back the color it was placed in.
+	for _, element := range []byte{E_KEY, E_DOOR, E_PASSAGE, E_NORMAL} {
+		for c := 0; c <= 0xFF; c++ {
+			color := EditorElementColor(ElementDefs[element].Color, byte(c))
+			picked, ok := EditorTileColor(TTile{Element: element, Color: color})
+			assert.True(ok)
+			assert.Equal(color, EditorElementColor(ElementDefs[element].Color, picked))
+		}
+	}
+	for c := byte(9); c <= 15; c++ {
+		picked, _ := EditorTileColor(TTile{Element: EditorTextElement(c), Color: 'A'})
+		assert.Equal(c, picked)
+	}
+	_, ok := EditorTileColor(TTile{Element: E_EMPTY})
+	assert.False(ok)
+}
+
+func TestEditorColorNames(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("Yellow", EditorColorLabel(0x0E))
+	assert.Equal("$1E", EditorColorLabel(0x1E))
+	assert.Equal("Yellow", EditorColorName(0x0E))
+	assert.Equal("Yellow on Dark blue, blinking", EditorColorName(0x9E))
+
+	// C goes back to the seven colors from any other attribute.
+	assert.Equal(byte(10), EditorNextColor(9))
+	assert.Equal(byte(9), EditorNextColor(15))
+	assert.Equal(byte(9), EditorNextColor(0x9F))
+	assert.Equal(byte(9), EditorNextColor(0x1C))
+}
+
+func TestEditorRecentColor(t *testing.T) {
+	assert := assert.New(t)
+	var recent []byte
+	for c := byte(1); c <= 10; c++ {
+		recent = EditorRecentColor(recent, c)
+	}
+	assert.Equal([]byte{10, 9, 8, 7, 6, 5, 4, 3}, recent)
+	recent = EditorRecentColor(recent, 6)
+	assert.Equal([]byte{6, 10, 9, 8, 7, 5, 4, 3}, recent)
+}
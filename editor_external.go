@@ -0,0 +1,220 @@
+//go:build editor
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Editor external code editing - a stat's code is written to a temporary
+// file and opened in a text editor of the user's choice. Every time the file
+// is saved, the code is read back in.
+
+var ErrEditorNoCommand = errors.New("No editor set!")
+
+// EditorExternalCommand returns the text editor given with /E:, or else the
+// one in $VISUAL or $EDITOR. Blank ones count as not set.
+func EditorExternalCommand() string {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && UpCase(pArg[1]) == 'E' && pArg[2] == ':' {
+			return strings.TrimSpace(pArg[3:])
+		}
+	}
+	if command := strings.TrimSpace(os.Getenv("VISUAL")); Length(command) != 0 {
+		return command
+	}
+	return strings.TrimSpace(os.Getenv("EDITOR"))
+}
+
+// editorSplitCommand splits a command into words at the spaces outside of
+// quotes, single or double. Backslashes are kept as they are, as Windows
+// paths are made of them.
+func editorSplitCommand(command string) (args []string) {
+	var word strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return
+}
+
+var editorUnicodeCP437 map[rune]byte
+
+// editorCodeToText converts code to UTF-8 text, one line per line of code.
+func editorCodeToText(code []byte) string {
+	var sb strings.Builder
+	for _, ch := range code {
+		if ch == '\r' {
+			sb.WriteByte('\n')
+		} else if ch >= 0x20 && ch < 0x7F || ch == '\t' {
+			sb.WriteByte(ch)
+		} else {
+			sb.WriteRune(cp437UnicodeMap[ch])
+		}
+	}
+	return sb.String()
+}
+
+// editorTextToCode converts UTF-8 text back to code. Characters missing
+// from the character set become question marks.
+func editorTextToCode(text string) (code []byte) {
+	if editorUnicodeCP437 == nil {
+		editorUnicodeCP437 = make(map[rune]byte)
+		for i := len(cp437UnicodeMap) - 1; i > 0; i-- {
+			if cp437UnicodeMap[i] >= 0x80 {
+				editorUnicodeCP437[cp437UnicodeMap[i]] = byte(i)
+			}
+		}
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if Length(text) != 0 && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	code = make([]byte, 0, len(text))
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		if r == '\n' {
+			code = append(code, '\r')
+		} else if r < 0x80 {
+			code = append(code, byte(r))
+		} else if ch, ok := editorUnicodeCP437[r]; ok {
+			code = append(code, ch)
+		} else {
+			code = append(code, '?')
+		}
+	}
+	return
+}
+
+// EditorSetStatCode replaces the code of a stat, along with every stat on
+// the board bound to it.
+func EditorSetStatCode(statId int16, code []byte) {
+	oldData := Board.Stats.At(statId).Data
+	for i := int16(0); i <= Board.Stats.Count; i++ {
+		stat := Board.Stats.At(i)
+		if i == statId || oldData != nil && stat.Data == oldData {
+			stat.Data = &code
+			stat.DataLen = int16(len(code))
+			if stat.DataPos > stat.DataLen {
+				stat.DataPos = 0
+			}
+		}
+	}
+}
+
+// EditorEditStatExternal opens the code of a stat in the given text editor,
+// and waits for it to exit, or for Escape to be pressed. The code is read
+// back each time the file is saved. Words of the command can be quoted, for
+// paths with spaces; a blank command is ErrEditorNoCommand.
+func EditorEditStatExternal(statId int16, command string) (err error) {
+	var reloads int16
+	args := editorSplitCommand(command)
+	if len(args) == 0 {
+		return ErrEditorNoCommand
+	}
+	stat := Board.Stats.At(statId)
+	f, err := os.CreateTemp("", "openzoo-*.txt")
+	if err != nil {
+		return
+	}
+	filename := f.Name()
+	defer os.Remove(filename)
+	if stat.Data != nil && stat.DataLen > 0 {
+		_, err = f.WriteString(editorCodeToText((*stat.Data)[:stat.DataLen]))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return
+	}
+	modTime, size := info.ModTime(), info.Size()
+	seenTime, seenSize := modTime, size
+
+	cmd := exec.Command(args[0], append(args[1:], filename)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	reload := func(force bool) {
+		info, err := os.Stat(filename)
+		if err != nil || info.ModTime().Equal(modTime) && info.Size() == size {
+			return
+		}
+		// The file has to stay the same for a frame, so that it is not read
+		// while still being written.
+		if !force && !(info.ModTime().Equal(seenTime) && info.Size() == seenSize) {
+			seenTime, seenSize = info.ModTime(), info.Size()
+			return
+		}
+		modTime, size = info.ModTime(), info.Size()
+		if text, err := os.ReadFile(filename); err == nil {
+			EditorSetStatCode(statId, editorTextToCode(string(text)))
+			reloads++
+			SidebarClearLine(8)
+			VideoWriteText(63, 8, 0x1F, "Reloaded: "+Str(reloads))
+		}
+	}
+
+	SidebarClear()
+	VideoWriteText(63, 4, 0x1E, "Editing code in")
+	VideoWriteText(63, 5, 0x1E, Copy(filepath.Base(args[0]), 1, 16))
+	VideoWriteText(61, 11, 0x70, " Esc ")
+	VideoWriteText(67, 11, 0x1F, "Stop waiting")
+	for {
+		select {
+		case err = <-done:
+			reload(true)
+			return
+		default:
+		}
+		Idle(IdleUntilFrame)
+		InputUpdate()
+		reload(false)
+		if InputKeyPressed == KEY_ESCAPE {
+			InputKeyPressed = '\x00'
+			reload(true)
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build editor
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorCodeText(t *testing.T) {
+	assert := assert.New(t)
+
+	code := []byte("@gem\r#char 4\r:touch\r\x04 \xdb\xb0\r")
+	assert.Equal("@gem\n#char 4\n:touch\n♦ █░\n", editorCodeToText(code))
+	assert.Equal(code, editorTextToCode(editorCodeToText(code)))
+	assert.Equal([]byte("a\rb\r"), editorTextToCode("a\r\nb"))
+	assert.Equal([]byte("?\r"), editorTextToCode("€\n"))
+}
+
+func TestEditorEditStatExternal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	data := []byte("#end\r")
+	for i := int16(1); i <= 2; i++ {
+		AddStat(i, 1, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+		Board.Stats.At(i).Data = &data
+		Board.Stats.At(i).DataLen = int16(len(data))
+	}
+
+	script := filepath.Join(t.TempDir(), "edit code.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\nprintf '@door\\n#end\\n' > \"$1\"\n"), 0755)
+	assert.Nil(EditorEditStatExternal(1, `"`+script+`"`))
+
+	for i := int16(1); i <= 2; i++ {
+		stat := Board.Stats.At(i)
+		assert.Equal("@door\r#end\r", string((*stat.Data)[:stat.DataLen]))
+	}
+	assert.Equal("#end\r", string(data))
+
+	assert.NotNil(EditorEditStatExternal(1, filepath.Join(t.TempDir(), "missing")))
+	assert.Equal(ErrEditorNoCommand, EditorEditStatExternal(1, " \t"))
+}
+
+func TestEditorExternalCommand(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{`C:\Program Files\Code\code.exe`, "--wait"},
+		editorSplitCommand(`"C:\Program Files\Code\code.exe" --wait`))
+	assert.Equal([]string{"vim", "+set ft=zzt", ""}, editorSplitCommand(` vim '+set ft=zzt' "" `))
+	assert.Empty(editorSplitCommand(" \t"))
+
+	t.Setenv("VISUAL", "\t")
+	t.Setenv("EDITOR", " vi ")
+	assert.Equal("vi", EditorExternalCommand())
+	t.Setenv("EDITOR", " ")
+	assert.Equal("", EditorExternalCommand())
+}
@@ -0,0 +1,180 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Editor find and replace - searching code and board names, and replacing
+// tiles, on one board or across the world
+
+type TEditorTextMatch struct {
+	Board  int16
+	StatId int16 // -1 for a board name
+	X, Y   int16
+	Line   int16
+	Text   string
+}
+
+// EditorFindText lists the board names and the lines of stat code which
+// contain text, ignoring case.
+func EditorFindText(text string) (matches []TEditorTextMatch) {
+	text = strings.ToUpper(text)
+	currentBoard := World.Info.CurrentBoard
+	BoardClose()
+	for i := int16(0); i < int16(len(World.BoardData)); i++ {
+		BoardOpen(i)
+		if strings.Contains(strings.ToUpper(Board.Name), text) {
+			matches = append(matches, TEditorTextMatch{Board: i, StatId: -1, Text: Board.Name})
+		}
+		for j := int16(0); j <= Board.Stats.Count; j++ {
+			stat := Board.Stats.At(j)
+			// Bound stats have no code of their own.
+			if stat.Data == nil || stat.DataLen <= 0 {
+				continue
+			}
+			for n, line := range strings.Split(string((*stat.Data)[:stat.DataLen]), "\r") {
+				if strings.Contains(strings.ToUpper(line), text) {
+					matches = append(matches, TEditorTextMatch{
+						Board:  i,
+						StatId: j,
+						X:      int16(stat.X),
+						Y:      int16(stat.Y),
+						Line:   int16(n + 1),
+						Text:   line,
+					})
+				}
+			}
+		}
+	}
+	BoardOpen(currentBoard)
+	return
+}
+
+func editorTextMatchLine(m *TEditorTextMatch) string {
+	var line string
+	if m.StatId < 0 {
+		line = fmt.Sprintf("%3d Title: %s", m.Board, m.Text)
+	} else {
+		line = fmt.Sprintf("%3d %2d,%-2d %s", m.Board, m.X, m.Y, m.Text)
+	}
+	return Copy(line, 1, TextWindowWidth-8)
+}
+
+// EditorBrowseTextMatches asks for the text to look for and lists where it
+// was found. It returns false if nothing was picked.
+func EditorBrowseTextMatches(text *string) (match TEditorTextMatch, ok bool) {
+	var textWindow TTextWindowState
+	PopupPromptString("Find in code and board titles:", text)
+	if InputKeyPressed == KEY_ESCAPE || Length(*text) == 0 {
+		return
+	}
+	matches := EditorFindText(*text)
+
+	textWindow.Init()
+	textWindow.Title = "Found \"" + Copy(*text, 1, 20) + "\" " + Str(int16(len(matches))) + " time(s)"
+	textWindow.Selectable = true
+	for i := range matches {
+		textWindow.Append(editorTextMatchLine(&matches[i]))
+	}
+	if len(matches) == 0 {
+		textWindow.Append("$Not found.")
+	}
+	textWindow.DrawOpen()
+	textWindow.Select(false, false)
+	textWindow.DrawClose()
+	if TextWindowRejected || textWindow.LinePos > len(matches) {
+		return
+	}
+	return matches[textWindow.LinePos-1], true
+}
+
+// TEditorTileQuery matches tiles by their element, color, or both.
+type TEditorTileQuery struct {
+	Element, Color     byte
+	ByElement, ByColor bool
+}
+
+func (q TEditorTileQuery) Matches(tile TTile) bool {
+	return (!q.ByElement || tile.Element == q.Element) && (!q.ByColor || tile.Color == q.Color)
+}
+
+// editorForEachBoard runs fn with every board open in turn, or just the
+// current one unless world is set.
+func editorForEachBoard(world bool, fn func(boardId int16)) {
+	if !world {
+		fn(World.Info.CurrentBoard)
+		return
+	}
+	currentBoard := World.Info.CurrentBoard
+	BoardClose()
+	for i := int16(0); i < int16(len(World.BoardData)); i++ {
+		BoardOpen(i)
+		fn(i)
+		BoardClose()
+	}
+	BoardOpen(currentBoard)
+}
+
+// EditorFindTiles returns the tiles of the current board within a rectangle
+// which match the query.
+func EditorFindTiles(query TEditorTileQuery, x1, y1, x2, y2 int16) (points []TCoord) {
+	var ix, iy int16
+	x1, y1, x2, y2 = editorNormalizeRect(x1, y1, x2, y2)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if query.Matches(Board.Tiles.Get(ix, iy)) {
+				points = append(points, TCoord{ix, iy})
+			}
+		}
+	}
+	return
+}
+
+// EditorCountTiles counts the matching tiles within a rectangle, on the
+// current board or on every board.
+func EditorCountTiles(query TEditorTileQuery, world bool, x1, y1, x2, y2 int16) (count int) {
+	editorForEachBoard(world, func(boardId int16) {
+		count += len(EditorFindTiles(query, x1, y1, x2, y2))
+	})
+	return
+}
+
+// EditorReplaceTiles gives the matching tiles the element of replacement, its
+// color, or both. If a stat is given, it is copied to every tile whose
+// element is replaced. Tiles are skipped if they hold the player and would
+// change element, or if a board runs out of stats.
+func EditorReplaceTiles(query TEditorTileQuery, world bool, x1, y1, x2, y2 int16,
+	replacement TTile, stat *TStat, byElement, byColor bool) (replaced, skipped int) {
+
+	editorForEachBoard(world, func(boardId int16) {
+		for _, p := range EditorFindTiles(query, x1, y1, x2, y2) {
+			tile := Board.Tiles.Get(p.X, p.Y)
+			color := tile.Color
+			if byColor {
+				color = replacement.Color
+			}
+			if byElement && (tile.Element != replacement.Element || stat != nil) {
+				if stat != nil && GetStatIdAt(p.X, p.Y) < 0 && Board.Stats.Count >= MAX_STAT {
+					skipped++
+					continue
+				}
+				if !BoardPrepareTileForPlacement(p.X, p.Y) {
+					skipped++
+					continue
+				}
+				if stat != nil {
+					AddStat(p.X, p.Y, replacement.Element, int16(color), stat.Cycle, *stat)
+				} else {
+					Board.Tiles.Set(p.X, p.Y, TTile{Element: replacement.Element, Color: color})
+				}
+			} else {
+				Board.Tiles.SetColor(p.X, p.Y, color)
+			}
+			replaced++
+		}
+	})
+	return
+}
@@ -0,0 +1,76 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorFindText(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	data := []byte("@door\r:touch\r#give gems 5\r")
+	AddStat(10, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	Board.Stats.At(1).Data = &data
+	Board.Stats.At(1).DataLen = int16(len(data))
+	Board.Name = "Gem Room"
+
+	matches := EditorFindText("GEM")
+	assert.Equal(2, len(matches))
+	assert.Equal(int16(-1), matches[0].StatId)
+	assert.Equal(int16(1), matches[1].StatId)
+	assert.Equal(int16(3), matches[1].Line)
+	assert.Equal("#give gems 5", matches[1].Text)
+	assert.Equal(0, len(EditorFindText("zap")))
+}
+
+func TestEditorReplaceTiles(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	for i := int16(1); i <= 2; i++ {
+		BoardClose()
+		World.BoardData = append(World.BoardData, nil)
+		World.Info.CurrentBoard = i
+		BoardCreate()
+		for ix := int16(1); ix <= 4; ix++ {
+			Board.Tiles.Set(ix, 1, TTile{Element: E_SOLID, Color: byte(0x0A + ix%2)})
+		}
+	}
+	BoardClose()
+	BoardOpen(1)
+
+	solid := TEditorTileQuery{Element: E_SOLID, ByElement: true}
+	green := TEditorTileQuery{Element: E_SOLID, Color: 0x0A, ByElement: true, ByColor: true}
+	assert.Equal(4, EditorCountTiles(solid, false, 1, 1, BOARD_WIDTH, BOARD_HEIGHT))
+	assert.Equal(8, EditorCountTiles(solid, true, 1, 1, BOARD_WIDTH, BOARD_HEIGHT))
+	assert.Equal(4, EditorCountTiles(green, true, 1, 1, BOARD_WIDTH, BOARD_HEIGHT))
+	assert.Equal(2, EditorCountTiles(solid, false, 1, 1, 2, 1))
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+
+	replaced, skipped := EditorReplaceTiles(green, true, 1, 1, BOARD_WIDTH, BOARD_HEIGHT,
+		TTile{Element: E_BREAKABLE, Color: 0x0C}, nil, true, false)
+	assert.Equal(4, replaced)
+	assert.Equal(0, skipped)
+	assert.Equal(TTile{Element: E_BREAKABLE, Color: 0x0A}, Board.Tiles.Get(2, 1))
+	assert.Equal(TTile{Element: E_SOLID, Color: 0x0B}, Board.Tiles.Get(1, 1))
+
+	// Objects get a stat each; the player is left alone.
+	template := StatTemplateDefault
+	template.P1 = 'X'
+	EditorReplaceTiles(solid, false, 1, 1, BOARD_WIDTH, BOARD_HEIGHT,
+		TTile{Element: E_OBJECT, Color: 0x0E}, &template, true, true)
+	assert.Equal(int16(2), Board.Stats.Count)
+	assert.Equal(byte(E_OBJECT), Board.Tiles.Get(3, 1).Element)
+	assert.Equal(byte('X'), Board.Stats.At(2).P1)
+
+	player := TEditorTileQuery{Element: E_PLAYER, ByElement: true}
+	_, skipped = EditorReplaceTiles(player, false, 1, 1, BOARD_WIDTH, BOARD_HEIGHT,
+		TTile{Element: E_EMPTY}, nil, true, false)
+	assert.Equal(1, skipped)
+}
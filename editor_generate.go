@@ -0,0 +1,244 @@
+//go:build editor
+
+package main
+
+// Board generators - mazes, caves, forests, lakes and creatures, made up to
+// fill a region for a quick start. Tiles with stats, like the player, are
+// left alone by all of them.
+
+type TEditorGenerator struct {
+	Name    string
+	Density bool // asks for a density, from 1 to 9
+	// Generate fills a region, in material where it calls for walls and in
+	// the editor's color where it places elements of their own, and returns
+	// how many tiles it could not fill for the lack of stats.
+	Generate func(x1, y1, x2, y2, density int16, material TTile, color byte) (skipped int)
+}
+
+var EditorGenerators = []TEditorGenerator{
+	{"Maze", false, EditorGenerateMaze},
+	{"Cave", true, EditorGenerateCave},
+	{"Forest", true, EditorGenerateForest},
+	{"Lake", true, EditorGenerateLake},
+	{"Lions and tigers", true, editorCreatureGenerator(E_LION, E_TIGER)},
+	{"Bears and ruffians", true, editorCreatureGenerator(E_BEAR, E_RUFFIAN)},
+	{"Slimes and spinning guns", true, editorCreatureGenerator(E_SLIME, E_SPINNING_GUN)},
+}
+
+func editorGenerateSet(x, y int16, tile TTile) {
+	if GetStatIdAt(x, y) < 0 {
+		Board.Tiles.Set(x, y, tile)
+	}
+}
+
+// editorCountNeighbors counts the 8 tiles around x, y for which is returns
+// true; those outside the region count if outside is set.
+func editorCountNeighbors(x, y, x1, y1, x2, y2 int16, outside bool, is func(x, y int16) bool) (count int) {
+	var ix, iy int16
+	for iy = y - 1; iy <= y+1; iy++ {
+		for ix = x - 1; ix <= x+1; ix++ {
+			if ix == x && iy == y {
+				continue
+			}
+			if ix < x1 || iy < y1 || ix > x2 || iy > y2 {
+				if outside {
+					count++
+				}
+			} else if is(ix, iy) {
+				count++
+			}
+		}
+	}
+	return
+}
+
+// EditorGenerateMaze fills the region with walls and digs a maze through
+// them, with one way between any two of its passages.
+func EditorGenerateMaze(x1, y1, x2, y2, density int16, material TTile, color byte) int {
+	var ix, iy int16
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			editorGenerateSet(ix, iy, material)
+		}
+	}
+	// Passages are on every other tile, starting at the corner; the tiles
+	// between them are dug out as the maze grows.
+	dug := make(map[TCoord]bool)
+	stack := []TCoord{{x1, y1}}
+	dug[stack[0]] = true
+	editorGenerateSet(x1, y1, TTile{Element: E_EMPTY})
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		var next []int
+		for dir := 0; dir <= 3; dir++ {
+			to := TCoord{cell.X + NeighborDeltaX[dir]*2, cell.Y + NeighborDeltaY[dir]*2}
+			if to.X >= x1 && to.Y >= y1 && to.X <= x2 && to.Y <= y2 && !dug[to] {
+				next = append(next, dir)
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		dir := next[Random(len(next))]
+		to := TCoord{cell.X + NeighborDeltaX[dir]*2, cell.Y + NeighborDeltaY[dir]*2}
+		editorGenerateSet(cell.X+NeighborDeltaX[dir], cell.Y+NeighborDeltaY[dir], TTile{Element: E_EMPTY})
+		editorGenerateSet(to.X, to.Y, TTile{Element: E_EMPTY})
+		dug[to] = true
+		stack = append(stack, to)
+	}
+	return 0
+}
+
+// EditorGenerateCave fills the region with cave walls, grown from noise;
+// the denser, the more walls.
+func EditorGenerateCave(x1, y1, x2, y2, density int16, material TTile, color byte) int {
+	var ix, iy int16
+	width, height := x2-x1+1, y2-y1+1
+	walls := make([][]bool, height)
+	for iy = 0; iy < height; iy++ {
+		walls[iy] = make([]bool, width)
+		for ix = 0; ix < width; ix++ {
+			walls[iy][ix] = Random(100) < 30+density*3
+		}
+	}
+	isWall := func(x, y int16) bool {
+		return walls[y-y1][x-x1]
+	}
+	for i := 0; i < 4; i++ {
+		next := make([][]bool, height)
+		for iy = 0; iy < height; iy++ {
+			next[iy] = make([]bool, width)
+			for ix = 0; ix < width; ix++ {
+				count := editorCountNeighbors(x1+ix, y1+iy, x1, y1, x2, y2, true, isWall)
+				next[iy][ix] = count >= 5 || walls[iy][ix] && count >= 4
+			}
+		}
+		walls = next
+	}
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if isWall(ix, iy) {
+				editorGenerateSet(ix, iy, material)
+			} else {
+				editorGenerateSet(ix, iy, TTile{Element: E_EMPTY})
+			}
+		}
+	}
+	return 0
+}
+
+// EditorGenerateForest plants clumps of trees on the empty tiles of the
+// region.
+func EditorGenerateForest(x1, y1, x2, y2, density int16, material TTile, color byte) int {
+	var ix, iy int16
+	trees := make(map[TCoord]bool)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if Random(10) < density {
+				trees[TCoord{ix, iy}] = true
+			}
+		}
+	}
+	isTree := func(x, y int16) bool {
+		return trees[TCoord{x, y}]
+	}
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			// Lone trees are cut down, and gaps in a clump are filled.
+			count := editorCountNeighbors(ix, iy, x1, y1, x2, y2, false, isTree)
+			if (count >= 5 || isTree(ix, iy) && count >= 2) && Board.Tiles.Get(ix, iy).Element == E_EMPTY {
+				editorGenerateSet(ix, iy, TTile{Element: E_FOREST, Color: ElementDefs[E_FOREST].Color})
+			}
+		}
+	}
+	return 0
+}
+
+// EditorGenerateLake puts an uneven pool of water in the middle of the
+// region; the denser, the larger.
+func EditorGenerateLake(x1, y1, x2, y2, density int16, material TTile, color byte) int {
+	var ix, iy int16
+	cx := float64(x1+x2) / 2
+	cy := float64(y1+y2) / 2
+	rx := float64(x2-x1+1) * float64(density+1) / 20
+	ry := float64(y2-y1+1) * float64(density+1) / 20
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			fx := (float64(ix) - cx) / rx
+			fy := (float64(iy) - cy) / ry
+			if fx*fx+fy*fy <= 0.8+float64(Random(5))/10 {
+				editorGenerateSet(ix, iy, TTile{Element: E_WATER, Color: ElementDefs[E_WATER].Color})
+			}
+		}
+	}
+	return 0
+}
+
+// editorCreatureStat returns a stat for a new creature, with the settings
+// last used in the editor.
+func editorCreatureStat(element byte) TStat {
+	stat := StatTemplateDefault
+	if Length(ElementDefs[element].Param1Name) != 0 {
+		stat.P1 = EditorStatSettings[element].P1
+	}
+	if Length(ElementDefs[element].Param2Name) != 0 {
+		stat.P2 = EditorStatSettings[element].P2
+	}
+	if Length(ElementDefs[element].ParamDirName) != 0 {
+		stat.StepX = EditorStatSettings[element].StepX
+		stat.StepY = EditorStatSettings[element].StepY
+	}
+	return stat
+}
+
+// editorCreatureGenerator returns a generator placing the creatures given
+// on empty tiles, one on every hundred tiles per density level, colored as
+// the editor would place them.
+func editorCreatureGenerator(elements ...byte) func(x1, y1, x2, y2, density int16, material TTile, color byte) int {
+	return func(x1, y1, x2, y2, density int16, material TTile, color byte) (skipped int) {
+		var ix, iy int16
+		for iy = y1; iy <= y2; iy++ {
+			for ix = x1; ix <= x2; ix++ {
+				if Board.Tiles.Get(ix, iy).Element != E_EMPTY || Random(100) >= density {
+					continue
+				}
+				if Board.Stats.Count >= MAX_STAT {
+					skipped++
+					continue
+				}
+				element := elements[Random(len(elements))]
+				AddStat(ix, iy, element, int16(EditorElementColor(ElementDefs[element].Color, color)),
+					ElementDefs[element].Cycle, editorCreatureStat(element))
+			}
+		}
+		return
+	}
+}
+
+// EditorGenerateMenu asks which generator to use and, if it has one, the
+// density. It returns false if either was cancelled.
+func EditorGenerateMenu(generator *int, density *byte) bool {
+	var state TTextWindowState
+	state.Init()
+	state.Title = "Generate"
+	state.Selectable = true
+	state.LinePos = *generator + 1
+	for _, g := range EditorGenerators {
+		state.Append(g.Name)
+	}
+	state.DrawOpen()
+	state.Select(false, false)
+	state.DrawClose()
+	if TextWindowRejected {
+		return false
+	}
+	*generator = state.LinePos - 1
+	if EditorGenerators[*generator].Density {
+		SidebarPromptSlider(true, 63, 3, "Density:", density)
+		if InputKeyPressed == KEY_ESCAPE {
+			return false
+		}
+	}
+	return true
+}
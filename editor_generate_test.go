@@ -0,0 +1,61 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorGenerators(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	wall := TTile{Element: E_NORMAL, Color: 0x0E}
+	playerX, playerY := int16(Board.Stats.At(0).X), int16(Board.Stats.At(0).Y)
+
+	// Every passage of a maze can be reached from its corner.
+	EditorGenerateMaze(1, 1, BOARD_WIDTH, BOARD_HEIGHT, 5, wall, 0x0E)
+	assert.Equal(byte(E_PLAYER), Board.Tiles.Get(playerX, playerY).Element)
+	assert.Equal(byte(E_NORMAL), Board.Tiles.Get(2, 2).Element)
+	reached := EditorFloodRegion(1, 1, false)
+	assert.Contains(reached, TCoord{59, 25})
+	assert.NotContains(reached, TCoord{60, 1})
+
+	EditorGenerateCave(1, 1, 20, 10, 5, wall, 0x0E)
+	walls := 0
+	for iy := int16(1); iy <= 10; iy++ {
+		for ix := int16(1); ix <= 20; ix++ {
+			if Board.Tiles.Get(ix, iy).Element == E_NORMAL {
+				walls++
+			}
+		}
+	}
+	assert.True(walls > 0 && walls < 200)
+
+	EditorClearRegion(1, 1, BOARD_WIDTH, BOARD_HEIGHT)
+	EditorGenerateLake(1, 1, BOARD_WIDTH, BOARD_HEIGHT, 5, wall, 0x0E)
+	assert.Equal(byte(E_WATER), Board.Tiles.Get(30, 13).Element)
+	assert.Equal(byte(E_EMPTY), Board.Tiles.Get(1, 1).Element)
+
+	// Creatures stop at the stat limit.
+	skipped := editorCreatureGenerator(E_LION, E_TIGER)(1, 1, BOARD_WIDTH, BOARD_HEIGHT, 20, wall, 0x0E)
+	assert.Equal(int16(MAX_STAT), Board.Stats.Count)
+	assert.True(skipped > 0)
+	lion := Board.Stats.At(1)
+	assert.Contains([]byte{E_LION, E_TIGER}, Board.Tiles.Get(int16(lion.X), int16(lion.Y)).Element)
+	assert.Equal(byte(E_EMPTY), lion.Under.Element)
+
+	// Creatures of the editor's color take it, as when placed by hand.
+	EditorClearRegion(1, 1, BOARD_WIDTH, BOARD_HEIGHT)
+	editorCreatureGenerator(E_SLIME, E_SPINNING_GUN)(1, 1, BOARD_WIDTH, BOARD_HEIGHT, 5, wall, 0x0C)
+	if assert.Greater(int(Board.Stats.Count), 1) {
+		for i := int16(1); i <= Board.Stats.Count; i++ {
+			stat := Board.Stats.At(i)
+			tile := Board.Tiles.Get(int16(stat.X), int16(stat.Y))
+			assert.Contains([]byte{E_SLIME, E_SPINNING_GUN}, tile.Element)
+			assert.Equal(byte(0x0C), tile.Color)
+		}
+	}
+}
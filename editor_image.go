@@ -0,0 +1,255 @@
+//go:build editor
+
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Image import - pictures turned into text-mode art
+//
+// The picture is stretched over the region, 8x14 pixels to a tile, as the
+// VGA font draws them. Each tile then gets the element, character and colors
+// which look the closest to its pixels: walls of any color, and, if allowed,
+// text of any character.
+
+const (
+	IMAGE_DITHER_NONE = iota
+	IMAGE_DITHER_ORDERED
+	IMAGE_DITHER_DIFFUSION
+)
+
+const (
+	imageCharWidth  = 8
+	imageCharHeight = 14
+)
+
+var ErrImageInvalid = errors.New("Not a PNG image!")
+
+type editorImageGlyph struct {
+	Element byte
+	Char    byte
+	Fg, Bg  []byte // the colors it can be drawn in
+	on      []int  // the pixels of the character which are set
+}
+
+var editorImageBayer = [4][4]int32{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// editorImageGlyphs lists what a tile can be made of. Earlier entries win
+// ties, so that blank black tiles stay empty.
+func editorImageGlyphs(text bool) (glyphs []editorImageGlyph) {
+	allColors := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	// Backgrounds from 8 up would blink.
+	backgrounds := allColors[:8]
+	glyphs = append(glyphs,
+		editorImageGlyph{E_EMPTY, ' ', []byte{0}, []byte{0}, nil},
+		editorImageGlyph{E_SOLID, ElementDefs[E_SOLID].Character, allColors, []byte{0}, nil},
+		editorImageGlyph{E_NORMAL, ElementDefs[E_NORMAL].Character, allColors, backgrounds, nil},
+		editorImageGlyph{E_BREAKABLE, ElementDefs[E_BREAKABLE].Character, allColors, backgrounds, nil},
+	)
+	if text {
+		// Text is white, on the background of its element.
+		for ch := 0; ch <= 255; ch++ {
+			glyphs = append(glyphs, editorImageGlyph{E_TEXT_WHITE, byte(ch), []byte{15}, backgrounds[:7], nil})
+		}
+	}
+	for i := range glyphs {
+		glyph := charsetData[int(glyphs[i].Char)*imageCharHeight:]
+		for ly := 0; ly < imageCharHeight; ly++ {
+			for lx := 0; lx < imageCharWidth; lx++ {
+				if glyph[ly]&(0x80>>lx) != 0 {
+					glyphs[i].on = append(glyphs[i].on, ly*imageCharWidth+lx)
+				}
+			}
+		}
+	}
+	return
+}
+
+// editorImageTile returns the tile drawing a glyph in the colors given.
+func editorImageTile(g editorImageGlyph, fg, bg byte) TTile {
+	switch {
+	case g.Element >= E_TEXT_MIN && bg == 0:
+		return TTile{Element: E_TEXT_WHITE, Color: g.Char}
+	case g.Element >= E_TEXT_MIN:
+		return TTile{Element: E_TEXT_MIN + bg - 1, Color: g.Char}
+	case g.Element == E_EMPTY:
+		return TTile{Element: E_EMPTY}
+	default:
+		return TTile{Element: g.Element, Color: bg<<4 | fg}
+	}
+}
+
+// editorImagePixels stretches an image to width x height pixels, averaging
+// the pixels each one covers. Transparent parts are drawn over black.
+func editorImagePixels(img image.Image, width, height int) [][3]int32 {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	pix := make([][3]int32, width*height)
+	for y := 0; y < height; y++ {
+		sy1 := bounds.Min.Y + y*sh/height
+		sy2 := Max(sy1+1, bounds.Min.Y+(y+1)*sh/height)
+		for x := 0; x < width; x++ {
+			sx1 := bounds.Min.X + x*sw/width
+			sx2 := Max(sx1+1, bounds.Min.X+(x+1)*sw/width)
+			var sum [3]int32
+			for sy := sy1; sy < sy2; sy++ {
+				for sx := sx1; sx < sx2; sx++ {
+					c := color.RGBAModel.Convert(img.At(sx, sy)).(color.RGBA)
+					sum[0] += int32(c.R)
+					sum[1] += int32(c.G)
+					sum[2] += int32(c.B)
+				}
+			}
+			n := int32((sx2 - sx1) * (sy2 - sy1))
+			pix[y*width+x] = [3]int32{sum[0] / n, sum[1] / n, sum[2] / n}
+		}
+	}
+	return pix
+}
+
+func editorImageDistance(a [3]int32, b [3]byte) int32 {
+	dr := a[0] - int32(b[0])
+	dg := a[1] - int32(b[1])
+	db := a[2] - int32(b[2])
+	return dr*dr + dg*dg + db*db
+}
+
+func editorImageNearest(p [3]int32) (nearest int) {
+	for c := 1; c < len(capturePalette); c++ {
+		if editorImageDistance(p, capturePalette[c]) < editorImageDistance(p, capturePalette[nearest]) {
+			nearest = c
+		}
+	}
+	return
+}
+
+// editorImageDither brings the pixels down to the 16 colors of the palette,
+// by an ordered pattern or by spreading the error of each pixel to the next
+// ones (Floyd-Steinberg).
+func editorImageDither(pix [][3]int32, width, height int, mode byte) {
+	if mode == IMAGE_DITHER_NONE {
+		return
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := pix[y*width+x]
+			if mode == IMAGE_DITHER_ORDERED {
+				offset := (editorImageBayer[y%4][x%4] - 8) * 6
+				p = [3]int32{p[0] + offset, p[1] + offset, p[2] + offset}
+			}
+			c := capturePalette[editorImageNearest(p)]
+			quantized := [3]int32{int32(c[0]), int32(c[1]), int32(c[2])}
+			if mode == IMAGE_DITHER_DIFFUSION {
+				spread := func(dx, dy int, weight int32) {
+					if x+dx < 0 || x+dx >= width || y+dy >= height {
+						return
+					}
+					q := &pix[(y+dy)*width+x+dx]
+					for i := 0; i < 3; i++ {
+						q[i] += (p[i] - quantized[i]) * weight / 16
+					}
+				}
+				spread(1, 0, 7)
+				spread(-1, 1, 3)
+				spread(0, 1, 5)
+				spread(1, 1, 1)
+			}
+			pix[y*width+x] = quantized
+		}
+	}
+}
+
+// EditorImageTiles converts an image to width x height tiles, row by row.
+func EditorImageTiles(img image.Image, width, height int16, dither byte, text bool) []TTile {
+	pixWidth := int(width) * imageCharWidth
+	pix := editorImagePixels(img, pixWidth, int(height)*imageCharHeight)
+	editorImageDither(pix, pixWidth, int(height)*imageCharHeight, dither)
+	glyphs := editorImageGlyphs(text)
+	tiles := make([]TTile, 0, int(width)*int(height))
+
+	var dist [16][imageCharWidth * imageCharHeight]int32
+	var total [16]int32
+	for ty := 0; ty < int(height); ty++ {
+		for tx := 0; tx < int(width); tx++ {
+			// How far each pixel of the tile is from each color
+			for c := range capturePalette {
+				total[c] = 0
+				for ly := 0; ly < imageCharHeight; ly++ {
+					for lx := 0; lx < imageCharWidth; lx++ {
+						p := pix[(ty*imageCharHeight+ly)*pixWidth+tx*imageCharWidth+lx]
+						d := editorImageDistance(p, capturePalette[c])
+						dist[c][ly*imageCharWidth+lx] = d
+						total[c] += d
+					}
+				}
+			}
+			// The foreground covers the pixels of the character which are
+			// set, and the background the others, so each is picked apart.
+			best, bestError := TTile{}, int32(-1)
+			for _, g := range glyphs {
+				sum := func(c byte) int32 {
+					var e int32
+					for _, i := range g.on {
+						e += dist[c][i]
+					}
+					return e
+				}
+				fg, fgError := byte(0), int32(-1)
+				for _, c := range g.Fg {
+					if e := sum(c); fgError < 0 || e < fgError {
+						fg, fgError = c, e
+					}
+				}
+				bg, bgError := byte(0), int32(-1)
+				for _, c := range g.Bg {
+					e := total[c] - sum(c)
+					if bgError < 0 || e < bgError {
+						bg, bgError = c, e
+					}
+				}
+				if bestError < 0 || fgError+bgError < bestError {
+					best, bestError = editorImageTile(g, fg, bg), fgError+bgError
+				}
+			}
+			tiles = append(tiles, best)
+		}
+	}
+	return tiles
+}
+
+// EditorImageLoad reads a PNG image.
+func EditorImageLoad(filename string) (image.Image, error) {
+	f, err := VfsOpen(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+	return img, nil
+}
+
+// EditorImageImport draws an image over a region of the board. Tiles with
+// stats are left as they are.
+func EditorImageImport(img image.Image, x1, y1, x2, y2 int16, dither byte, text bool) {
+	var ix, iy int16
+	tiles := EditorImageTiles(img, x2-x1+1, y2-y1+1, dither, text)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if GetStatIdAt(ix, iy) < 0 {
+				Board.Tiles.Set(ix, iy, tiles[int(iy-y1)*int(x2-x1+1)+int(ix-x1)])
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build editor
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorImageTiles(t *testing.T) {
+	assert := assert.New(t)
+	InitElementsEditor()
+	defer InitElementsGame()
+
+	// Three tiles: red, black, and white over blue, at twice the size.
+	img := image.NewRGBA(image.Rect(0, 0, 48, 28))
+	for y := 0; y < 28; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, color.RGBA{0xAA, 0, 0, 0xFF})
+		}
+		for x := 32; x < 48; x++ {
+			if y < 14 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 0xAA, 0xFF})
+			}
+		}
+	}
+	tiles := EditorImageTiles(img, 3, 1, IMAGE_DITHER_NONE, false)
+	assert.Equal(TTile{Element: E_SOLID, Color: 0x04}, tiles[0])
+	assert.Equal(TTile{Element: E_EMPTY}, tiles[1])
+	assert.True(tiles[2].Element < E_TEXT_MIN)
+	tiles = EditorImageTiles(img, 3, 1, IMAGE_DITHER_NONE, true)
+	assert.Equal(TTile{Element: E_TEXT_BLUE, Color: 0xDF}, tiles[2])
+
+	// A gray between two colors of the palette comes out as both.
+	gray := make([][3]int32, 64)
+	for i := range gray {
+		gray[i] = [3]int32{0x80, 0x80, 0x80}
+	}
+	for _, mode := range []byte{IMAGE_DITHER_ORDERED, IMAGE_DITHER_DIFFUSION} {
+		pix := append([][3]int32(nil), gray...)
+		editorImageDither(pix, 8, 8, mode)
+		seen := make(map[[3]int32]bool)
+		for _, p := range pix {
+			seen[p] = true
+		}
+		assert.Len(seen, 2)
+	}
+}
@@ -0,0 +1,148 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Editor stat inspector - every field of a stat, as stored, whether or not
+// its element gives it a name.
+
+type TEditorStatField struct {
+	Name     string
+	Min, Max int
+	Get      func(stat *TStat) int
+	Set      func(stat *TStat, value int)
+}
+
+// EditorStatFields lists the raw fields of a stat, with the values each can
+// be given on the current board. Changing X or Y moves the stat's tile along
+// with it.
+func EditorStatFields(statId int16) []TEditorStatField {
+	stat := Board.Stats.At(statId)
+	return []TEditorStatField{
+		{"X", 0, BOARD_WIDTH + 1,
+			func(s *TStat) int { return int(s.X) }, func(s *TStat, v int) { editorStatMove(s, int16(v), int16(s.Y)) }},
+		{"Y", 0, BOARD_HEIGHT + 1,
+			func(s *TStat) int { return int(s.Y) }, func(s *TStat, v int) { editorStatMove(s, int16(s.X), int16(v)) }},
+		{"Step X", -32768, 32767,
+			func(s *TStat) int { return int(s.StepX) }, func(s *TStat, v int) { s.StepX = int16(v) }},
+		{"Step Y", -32768, 32767,
+			func(s *TStat) int { return int(s.StepY) }, func(s *TStat, v int) { s.StepY = int16(v) }},
+		{"Cycle", 0, 32767,
+			func(s *TStat) int { return int(s.Cycle) }, func(s *TStat, v int) { s.Cycle = int16(v) }},
+		{"P1", 0, 255,
+			func(s *TStat) int { return int(s.P1) }, func(s *TStat, v int) { s.P1 = byte(v) }},
+		{"P2", 0, 255,
+			func(s *TStat) int { return int(s.P2) }, func(s *TStat, v int) { s.P2 = byte(v) }},
+		{"P3", 0, 255,
+			func(s *TStat) int { return int(s.P3) }, func(s *TStat, v int) { s.P3 = byte(v) }},
+		{"Follower", -1, int(Board.Stats.Count),
+			func(s *TStat) int { return int(s.Follower) }, func(s *TStat, v int) { s.Follower = int16(v) }},
+		{"Leader", -1, int(Board.Stats.Count),
+			func(s *TStat) int { return int(s.Leader) }, func(s *TStat, v int) { s.Leader = int16(v) }},
+		{"Under element", 0, MAX_ELEMENT,
+			func(s *TStat) int { return int(s.Under.Element) }, func(s *TStat, v int) { s.Under.Element = byte(v) }},
+		{"Under color", 0, 255,
+			func(s *TStat) int { return int(s.Under.Color) }, func(s *TStat, v int) { s.Under.Color = byte(v) }},
+		{"Code position", -1, int(stat.DataLen),
+			func(s *TStat) int { return int(s.DataPos) }, func(s *TStat, v int) { s.DataPos = int16(v) }},
+	}
+}
+
+// editorStatMove moves a stat and its tile to x, y, putting back the tile it
+// was on.
+func editorStatMove(stat *TStat, x, y int16) {
+	tile := Board.Tiles.Get(int16(stat.X), int16(stat.Y))
+	Board.Tiles.Set(int16(stat.X), int16(stat.Y), stat.Under)
+	stat.Under = Board.Tiles.Get(x, y)
+	Board.Tiles.Set(x, y, tile)
+	stat.X, stat.Y = byte(x), byte(y)
+}
+
+// EditorSetStatField parses and checks a new value for a field of a stat,
+// and sets it if it is valid. It returns what was wrong otherwise.
+func EditorSetStatField(statId int16, field *TEditorStatField, text string) (err string) {
+	value, convErr := strconv.Atoi(text)
+	if convErr != nil {
+		return "not a number!"
+	} else if value < field.Min || value > field.Max {
+		return "use " + Str(field.Min) + ".." + Str(field.Max)
+	} else if (field.Name == "Follower" || field.Name == "Leader") && value == int(statId) {
+		return "not itself!"
+	}
+	stat := Board.Stats.At(statId)
+	if field.Name == "X" || field.Name == "Y" {
+		x, y := int16(stat.X), int16(stat.Y)
+		if field.Name == "X" {
+			x = int16(value)
+		} else {
+			y = int16(value)
+		}
+		if id := GetStatIdAt(x, y); id >= 0 && id != statId {
+			return "stat in the way!"
+		}
+	}
+	field.Set(stat, value)
+	return ""
+}
+
+func editorStatFieldLine(field *TEditorStatField, stat *TStat) string {
+	line := fmt.Sprintf("%14s: %d", field.Name, field.Get(stat))
+	switch field.Name {
+	case "Under element":
+		line += " (" + ElementDefs[stat.Under.Element].Name + ")"
+	case "Follower", "Leader":
+		if field.Get(stat) < 0 {
+			line += " (none)"
+		}
+	}
+	return line
+}
+
+// EditorInspectStat shows every field of a stat, and edits the one picked
+// with Enter. It returns true if any was changed.
+func EditorInspectStat(statId int16) (changed bool) {
+	var state TTextWindowState
+	stat := Board.Stats.At(statId)
+	element := Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element
+	state.Init()
+	state.Title = "Stat " + Str(statId) + ": " + ElementDefs[element].Name
+	state.Selectable = true
+	state.DrawOpen()
+	for {
+		fields := EditorStatFields(statId)
+		state.Lines = make([]string, 0)
+		for i := range fields {
+			state.Append(editorStatFieldLine(&fields[i], stat))
+		}
+		state.Append(fmt.Sprintf("%14s: %d", "Code length", stat.DataLen))
+		state.Select(false, false)
+		if InputKeyPressed != KEY_ENTER {
+			break
+		}
+		if state.LinePos > len(fields) {
+			continue
+		}
+		field := &fields[state.LinePos-1]
+		numStr := Str(field.Get(stat))
+		SidebarPromptString(field.Name+"?", "", &numStr, PROMPT_ANY)
+		if InputKeyPressed == KEY_ESCAPE || Length(numStr) == 0 || numStr == Str(field.Get(stat)) {
+			continue
+		}
+		if err := EditorSetStatField(statId, field, numStr); Length(err) != 0 {
+			VideoWriteText(63, 4, 0x1E, "Bad value;")
+			VideoWriteText(63, 5, 0x1E, err)
+			PauseOnError()
+			SidebarClearLine(4)
+			SidebarClearLine(5)
+		} else {
+			changed = true
+		}
+	}
+	state.DrawClose()
+	InputKeyPressed = '\x00'
+	return
+}
@@ -0,0 +1,58 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorSetStatField(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	AddStat(5, 5, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	data := []byte("#end\r")
+	Board.Stats.At(1).Data = &data
+	Board.Stats.At(1).DataLen = int16(len(data))
+
+	field := func(name string) *TEditorStatField {
+		fields := EditorStatFields(1)
+		for i := range fields {
+			if fields[i].Name == name {
+				return &fields[i]
+			}
+		}
+		t.Fatalf("no field %s", name)
+		return nil
+	}
+
+	assert.Empty(EditorSetStatField(1, field("Step X"), "-2"))
+	assert.Equal(int16(-2), Board.Stats.At(1).StepX)
+	assert.Empty(EditorSetStatField(1, field("Under element"), Str(E_FAKE)))
+	assert.Equal(byte(E_FAKE), Board.Stats.At(1).Under.Element)
+	assert.Empty(EditorSetStatField(1, field("Leader"), "-1"))
+	assert.Empty(EditorSetStatField(1, field("Code position"), "5"))
+	assert.Equal(int16(5), Board.Stats.At(1).DataPos)
+
+	assert.Equal("not a number!", EditorSetStatField(1, field("P1"), "x"))
+	assert.Equal("use 0..255", EditorSetStatField(1, field("P1"), "256"))
+	assert.Equal("use -1..1", EditorSetStatField(1, field("Follower"), "2"))
+	assert.Equal("not itself!", EditorSetStatField(1, field("Follower"), "1"))
+	assert.Equal("use -1..5", EditorSetStatField(1, field("Code position"), "6"))
+	assert.Equal("use 0..53", EditorSetStatField(1, field("Under element"), "54"))
+	assert.Equal(byte(E_FAKE), Board.Stats.At(1).Under.Element)
+
+	// The tile moves with the stat, but not onto another one.
+	Board.Tiles.Set(7, 5, TTile{Element: E_FOREST, Color: 0x20})
+	assert.Empty(EditorSetStatField(1, field("X"), "7"))
+	assert.Equal(TTile{Element: E_FAKE}, Board.Tiles.Get(5, 5))
+	assert.Equal(TTile{Element: E_OBJECT, Color: 0x0F}, Board.Tiles.Get(7, 5))
+	assert.Equal(TTile{Element: E_FOREST, Color: 0x20}, Board.Stats.At(1).Under)
+	player := Board.Stats.At(0)
+	assert.Empty(EditorSetStatField(1, field("X"), Str(int(player.X))))
+	assert.Equal("stat in the way!", EditorSetStatField(1, field("Y"), Str(int(player.Y))))
+	assert.Equal(int16(1), GetStatIdAt(int16(player.X), 5))
+	assert.Equal(int16(0), GetStatIdAt(int16(player.X), int16(player.Y)))
+}
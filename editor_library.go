@@ -0,0 +1,242 @@
+//go:build editor
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/OpenZoo/openzoo-go/format"
+)
+
+// Editor object library - board regions, with their stats and code, kept
+// in a file of their own so that they can be stamped onto any board of any
+// world. An object is a region of one tile.
+//
+// The file holds a header and a count, followed by each entry: its name,
+// size, tiles, and stats as they are stored on boards. Stats sharing their
+// code refer to the first of them, like on boards, by a DataLen of minus its
+// index plus one.
+
+const EDITOR_LIBRARY_FILENAME = "OBJECTS.LIB"
+
+var editorLibraryMagic = []byte("OZLIB\x01")
+
+var ErrLibraryInvalid = errors.New("Not an object library!")
+
+type TEditorPrefab struct {
+	Name string
+	Clip *TEditorClipboard
+}
+
+func editorLibraryWrite(w io.Writer, prefabs []TEditorPrefab) error {
+	if _, err := w.Write(editorLibraryMagic); err != nil {
+		return err
+	}
+	if err := format.WritePShort(w, int16(len(prefabs))); err != nil {
+		return err
+	}
+	for _, p := range prefabs {
+		c := p.Clip
+		if err := format.WritePString(w, []byte(p.Name), BOARD_NAME_LENGTH); err != nil {
+			return err
+		}
+		if err := format.WritePShort(w, c.Width); err != nil {
+			return err
+		}
+		if err := format.WritePShort(w, c.Height); err != nil {
+			return err
+		}
+		for _, tile := range c.Tiles {
+			if _, err := w.Write([]byte{tile.Element, tile.Color}); err != nil {
+				return err
+			}
+		}
+		if err := format.WritePShort(w, int16(len(c.Stats))); err != nil {
+			return err
+		}
+		for i, stat := range c.Stats {
+			if stat.Data == nil {
+				stat.DataLen = 0
+			}
+			for j := 0; j < i && stat.DataLen > 0; j++ {
+				if c.Stats[j].Data == stat.Data {
+					stat.DataLen = int16(-j - 1)
+				}
+			}
+			if err := format.WriteStat(w, stat); err != nil {
+				return err
+			}
+			if stat.DataLen > 0 {
+				if err := format.WritePBytes(w, *stat.Data, int(stat.DataLen)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func editorLibraryRead(r io.Reader) (prefabs []TEditorPrefab, err error) {
+	var count, statCount int16
+	magic := make([]byte, len(editorLibraryMagic))
+	if _, err = io.ReadFull(r, magic); err != nil || string(magic) != string(editorLibraryMagic) {
+		return nil, ErrLibraryInvalid
+	}
+	if err = format.ReadPShort(r, &count); err != nil {
+		return
+	}
+	for ; count > 0; count-- {
+		var p TEditorPrefab
+		c := &TEditorClipboard{}
+		p.Clip = c
+		if err = format.ReadPString(r, &p.Name, BOARD_NAME_LENGTH); err != nil {
+			return
+		}
+		if err = format.ReadPShort(r, &c.Width); err != nil {
+			return
+		}
+		if err = format.ReadPShort(r, &c.Height); err != nil {
+			return
+		}
+		if c.Width < 1 || c.Width > BOARD_WIDTH || c.Height < 1 || c.Height > BOARD_HEIGHT {
+			return nil, ErrLibraryInvalid
+		}
+		c.Tiles = make([]TTile, c.Width*c.Height)
+		for i := range c.Tiles {
+			if err = format.ReadPByte(r, &c.Tiles[i].Element); err != nil {
+				return
+			}
+			if err = format.ReadPByte(r, &c.Tiles[i].Color); err != nil {
+				return
+			}
+		}
+		if err = format.ReadPShort(r, &statCount); err != nil {
+			return
+		}
+		if statCount < 0 || statCount > MAX_STAT {
+			return nil, ErrLibraryInvalid
+		}
+		c.Stats = make([]TStat, statCount)
+		for i := range c.Stats {
+			stat := &c.Stats[i]
+			if err = format.ReadStat(r, stat); err != nil {
+				return
+			}
+			if int16(stat.X) >= c.Width || int16(stat.Y) >= c.Height {
+				return nil, ErrLibraryInvalid
+			}
+			if stat.DataLen > 0 {
+				data := make([]byte, stat.DataLen)
+				if _, err = io.ReadFull(r, data); err != nil {
+					return
+				}
+				stat.Data = &data
+			} else if stat.DataLen < 0 {
+				j := int(-stat.DataLen - 1)
+				if j >= i {
+					return nil, ErrLibraryInvalid
+				}
+				stat.Data = c.Stats[j].Data
+				stat.DataLen = c.Stats[j].DataLen
+			}
+			if stat.Leader >= statCount {
+				stat.Leader = -1
+			}
+			if stat.Follower >= statCount {
+				stat.Follower = -1
+			}
+		}
+		prefabs = append(prefabs, p)
+	}
+	return
+}
+
+// EditorLibraryLoad reads the object library. A missing library is empty.
+func EditorLibraryLoad() ([]TEditorPrefab, error) {
+	f, err := VfsOpen(EDITOR_LIBRARY_FILENAME)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return editorLibraryRead(bufio.NewReader(f))
+}
+
+func EditorLibrarySave(prefabs []TEditorPrefab) error {
+	f, err := VfsCreate(EDITOR_LIBRARY_FILENAME)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err = editorLibraryWrite(w, prefabs); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func editorPrefabLine(p *TEditorPrefab) string {
+	return Copy(p.Name, 1, 30) + " (" + Str(p.Clip.Width) + "x" + Str(p.Clip.Height) + ", " +
+		Str(len(p.Clip.Stats)) + " stat(s))"
+}
+
+// EditorLibrary lists the object library. A adds what take returns under a
+// new name, Del deletes the highlighted entry, and Enter returns it.
+func EditorLibrary(take func() *TEditorClipboard) (picked *TEditorClipboard) {
+	const actionKeys = "AX\xd3"
+	var textWindow TTextWindowState
+	linePos := 1
+	for {
+		prefabs, err := EditorLibraryLoad()
+		if err != nil {
+			DisplayIOError(err)
+			return
+		}
+		textWindow.Init()
+		textWindow.Title = "Object library (A:Add Del:Delete)"
+		textWindow.Selectable = true
+		textWindow.ExitKeys = actionKeys
+		for i := range prefabs {
+			textWindow.Append(editorPrefabLine(&prefabs[i]))
+		}
+		if len(prefabs) == 0 {
+			textWindow.Append("$Empty; press A to add an object.")
+		}
+		textWindow.LinePos = Min(linePos, len(textWindow.Lines))
+		textWindow.DrawOpen()
+		textWindow.Select(false, false)
+		textWindow.DrawClose()
+		linePos = textWindow.LinePos
+		if TextWindowRejected {
+			return
+		}
+		switch UpCase(InputKeyPressed) {
+		case 'A':
+			var name string
+			PopupPromptString("Name for the library entry:", &name)
+			if InputKeyPressed == KEY_ESCAPE || Length(name) == 0 {
+				continue
+			}
+			prefabs = append(prefabs, TEditorPrefab{Name: Copy(name, 1, BOARD_NAME_LENGTH), Clip: take()})
+			linePos = len(prefabs)
+		case 'X', KEY_DELETE:
+			if linePos > len(prefabs) || !SidebarPromptYesNo("Delete entry? ", false) {
+				continue
+			}
+			prefabs = append(prefabs[:linePos-1], prefabs[linePos:]...)
+		default:
+			if linePos <= len(prefabs) {
+				return prefabs[linePos-1].Clip
+			}
+			continue
+		}
+		if err = EditorLibrarySave(prefabs); err != nil {
+			DisplayIOError(err)
+			return
+		}
+	}
+}
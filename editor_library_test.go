@@ -0,0 +1,68 @@
+//go:build editor
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorLibraryReadWrite(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	// Two objects bound together, next to a centipede.
+	data := []byte("@shop\r#end\r")
+	for i := int16(1); i <= 2; i++ {
+		AddStat(i, 1, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+		Board.Stats.At(i).Data = &data
+		Board.Stats.At(i).DataLen = int16(len(data))
+		Board.Stats.At(i).P1 = 2
+	}
+	AddStat(1, 2, E_CENTIPEDE_HEAD, 0x09, 2, StatTemplateDefault)
+	AddStat(2, 2, E_CENTIPEDE_SEGMENT, 0x09, 2, StatTemplateDefault)
+	Board.Stats.At(3).Follower = 4
+	Board.Stats.At(4).Leader = 3
+
+	prefabs := []TEditorPrefab{
+		{"Shopkeepers", EditorClipboardCopy(1, 1, 2, 2)},
+		{"Wall", EditorClipboardCopy(10, 10, 10, 10)},
+	}
+	var buf bytes.Buffer
+	assert.Nil(editorLibraryWrite(&buf, prefabs))
+	read, err := editorLibraryRead(bytes.NewReader(buf.Bytes()))
+	assert.Nil(err)
+	if !assert.Len(read, 2) {
+		return
+	}
+	assert.Equal("Shopkeepers", read[0].Name)
+	c := read[0].Clip
+	assert.Equal(prefabs[0].Clip.Tiles, c.Tiles)
+	if assert.Len(c.Stats, 4) {
+		assert.True(c.Stats[0].Data == c.Stats[1].Data)
+		assert.Equal(data, *c.Stats[1].Data)
+		assert.Equal(byte(2), c.Stats[1].P1)
+		assert.Equal(int16(3), c.Stats[2].Follower)
+		assert.Equal(int16(2), c.Stats[3].Leader)
+	}
+	assert.Equal(int16(1), read[1].Clip.Width)
+	assert.Empty(read[1].Clip.Stats)
+
+	// The entry pastes like a copy would.
+	assert.True(EditorClipboardPaste(c, 20, 20))
+	assert.Equal(byte(E_OBJECT), Board.Tiles.Get(21, 20).Element)
+	assert.Equal("shop", StatObjectName(Board.Stats.At(GetStatIdAt(21, 20))))
+
+	_, err = editorLibraryRead(bytes.NewReader([]byte("ZZT")))
+	assert.Equal(ErrLibraryInvalid, err)
+
+	// Stats outside of their entry are refused.
+	c.Stats[3].X = byte(c.Width)
+	buf.Reset()
+	assert.Nil(editorLibraryWrite(&buf, []TEditorPrefab{{"Stray", c}}))
+	_, err = editorLibraryRead(bytes.NewReader(buf.Bytes()))
+	assert.Equal(ErrLibraryInvalid, err)
+}
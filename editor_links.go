@@ -0,0 +1,347 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Editor links - the passages between boards, and the map of boards laid
+// out by their neighbor links
+//
+// A passage leads to the passage of the same color on its destination
+// board; without one, the player is left wherever the board was entered.
+// Neighbor links are kept per board and direction, and need not lead back.
+
+type TEditorPassage struct {
+	Board   int16
+	X, Y    int16
+	Color   byte
+	Dest    int16
+	Matched bool // the destination has a passage of the same color
+}
+
+// EditorCollectPassages lists the passages on every board, grouped by color.
+func EditorCollectPassages() (passages []TEditorPassage) {
+	type boardColor struct {
+		board int16
+		color byte
+	}
+	colors := make(map[boardColor]bool)
+	editorForEachBoard(true, func(boardId int16) {
+		for iy := int16(1); iy <= BOARD_HEIGHT; iy++ {
+			for ix := int16(1); ix <= BOARD_WIDTH; ix++ {
+				if tile := Board.Tiles.Get(ix, iy); tile.Element == E_PASSAGE {
+					colors[boardColor{boardId, tile.Color}] = true
+				}
+			}
+		}
+		for i := int16(1); i <= Board.Stats.Count; i++ {
+			stat := Board.Stats.At(i)
+			tile := Board.Tiles.Get(int16(stat.X), int16(stat.Y))
+			if tile.Element == E_PASSAGE {
+				passages = append(passages, TEditorPassage{
+					Board: boardId,
+					X:     int16(stat.X),
+					Y:     int16(stat.Y),
+					Color: tile.Color,
+					Dest:  int16(stat.P3),
+				})
+			}
+		}
+	})
+	for i := range passages {
+		passages[i].Matched = colors[boardColor{passages[i].Dest, passages[i].Color}]
+	}
+	sort.SliceStable(passages, func(i, j int) bool {
+		return passages[i].Color < passages[j].Color
+	})
+	return
+}
+
+// editorPassageColorName names a passage color by its background, which is
+// what the editor's color choice sets.
+func editorPassageColorName(color byte) string {
+	if bg := (color >> 4) & 0x07; bg != 0 {
+		return ColorNames[bg-1]
+	}
+	return fmt.Sprintf("$%02X", color)
+}
+
+// EditorBrowsePassages lists the passages by color, and returns the one
+// picked.
+func EditorBrowsePassages() (passage TEditorPassage, ok bool) {
+	var textWindow TTextWindowState
+	passages := EditorCollectPassages()
+	lines := make([]int, 0)
+	textWindow.Init()
+	textWindow.Title = "Passages (\x13: no way back)"
+	textWindow.Selectable = true
+	for i := range passages {
+		p := &passages[i]
+		if i == 0 || p.Color != passages[i-1].Color {
+			textWindow.Append("$" + editorPassageColorName(p.Color) + " passages")
+			lines = append(lines, -1)
+		}
+		mark := " "
+		if !p.Matched {
+			mark = "\x13"
+		}
+		dest := "?"
+		if int(p.Dest) < len(World.BoardData) {
+			dest = EditorGetBoardName(int(p.Dest), false)
+		}
+		textWindow.Append(Copy(fmt.Sprintf("%s%3d %2d,%-2d -> %3d %s", mark, p.Board, p.X, p.Y, p.Dest, dest), 1, TextWindowWidth-8))
+		lines = append(lines, i)
+	}
+	if len(passages) == 0 {
+		textWindow.Append("$No passages.")
+	}
+	textWindow.DrawOpen()
+	textWindow.Select(false, false)
+	textWindow.DrawClose()
+	if TextWindowRejected || textWindow.LinePos > len(lines) || lines[textWindow.LinePos-1] < 0 {
+		return
+	}
+	return passages[lines[textWindow.LinePos-1]], true
+}
+
+// EditorNeighborLinks returns the neighbor links of every board.
+func EditorNeighborLinks() (links [][4]byte) {
+	links = make([][4]byte, len(World.BoardData))
+	editorForEachBoard(true, func(boardId int16) {
+		links[boardId] = Board.Info.NeighborBoards
+	})
+	return
+}
+
+// EditorSetNeighborLink links a board to another in a direction, or unlinks
+// it given 0.
+func EditorSetNeighborLink(boardId int16, dir int, target byte) {
+	if boardId == World.Info.CurrentBoard {
+		Board.Info.NeighborBoards[dir] = target
+		return
+	}
+	currentBoard := World.Info.CurrentBoard
+	BoardClose()
+	BoardOpen(boardId)
+	Board.Info.NeighborBoards[dir] = target
+	BoardClose()
+	BoardOpen(currentBoard)
+}
+
+// EditorMakeLinksReciprocal links each neighbor of a board back to it, and
+// returns how many links were changed.
+func EditorMakeLinksReciprocal(boardId int16) (changed int) {
+	links := EditorNeighborLinks()
+	for dir, target := range links[boardId] {
+		if target == 0 || int(target) >= len(links) {
+			continue
+		}
+		// North and south, as well as west and east, are pairs.
+		if back := dir ^ 1; int16(links[target][back]) != boardId {
+			EditorSetNeighborLink(int16(target), back, byte(boardId))
+			changed++
+		}
+	}
+	return
+}
+
+// EditorWorldMapLayout places boards on a grid, starting from root and
+// following the neighbor links. A board is placed once, where it is first
+// reached, and only if the cell is free.
+func EditorWorldMapLayout(links [][4]byte, root int16) (cells map[TCoord]int16, places map[int16]TCoord) {
+	cells = map[TCoord]int16{{0, 0}: root}
+	places = map[int16]TCoord{root: {0, 0}}
+	queue := []int16{root}
+	for len(queue) > 0 {
+		boardId := queue[0]
+		queue = queue[1:]
+		for dir, target := range links[boardId] {
+			if target == 0 || int(target) >= len(links) {
+				continue
+			}
+			if _, ok := places[int16(target)]; ok {
+				continue
+			}
+			p := places[boardId]
+			cell := TCoord{p.X + NeighborDeltaX[dir], p.Y + NeighborDeltaY[dir]}
+			if _, ok := cells[cell]; !ok {
+				cells[cell] = int16(target)
+				places[int16(target)] = cell
+				queue = append(queue, int16(target))
+			}
+		}
+	}
+	return
+}
+
+const (
+	MAP_CELL_WIDTH  = 5
+	MAP_CELL_HEIGHT = 2
+	MAP_COLUMNS     = BOARD_WIDTH / MAP_CELL_WIDTH
+	MAP_ROWS        = (BOARD_HEIGHT - 1) / MAP_CELL_HEIGHT
+)
+
+// editorDrawLink draws the link between two neighboring cells, given
+// whether each leads to the other. Links leading elsewhere are marked *.
+func editorDrawLink(x, y int16, horizontal bool, there, back, thereElsewhere, backElsewhere bool) {
+	var arrowThere, arrowBack, line string
+	if horizontal {
+		arrowThere, arrowBack, line = "\x1a", "\x1b", "\xc4"
+	} else {
+		arrowThere, arrowBack, line = "\x19", "\x18", "\xb3"
+	}
+	var text string
+	color := byte(0x0C)
+	switch {
+	case there && back:
+		text, color = line, 0x0F
+	case there:
+		text = arrowThere
+	case back:
+		text = arrowBack
+	case thereElsewhere || backElsewhere:
+		text, color = "*", 0x0E
+	default:
+		return
+	}
+	if horizontal {
+		VideoWriteText(x, y, color, text+text)
+	} else {
+		VideoWriteText(x, y, color, text)
+	}
+}
+
+func editorDrawWorldMap(links [][4]byte, cells map[TCoord]int16, places map[int16]TCoord, selected TCoord) {
+	var ix, iy int16
+	origin := TCoord{selected.X - MAP_COLUMNS/2, selected.Y - MAP_ROWS/2}
+	for iy = 0; iy < BOARD_HEIGHT; iy++ {
+		VideoWriteText(0, iy, 0x00, fmt.Sprintf("%60s", ""))
+	}
+	for iy = 0; iy < MAP_ROWS; iy++ {
+		for ix = 0; ix < MAP_COLUMNS; ix++ {
+			cell := TCoord{origin.X + ix, origin.Y + iy}
+			boardId, ok := cells[cell]
+			if !ok {
+				if cell == selected {
+					VideoWriteText(ix*MAP_CELL_WIDTH, iy*MAP_CELL_HEIGHT+1, 0x70, "   ")
+				}
+				continue
+			}
+			sx, sy := ix*MAP_CELL_WIDTH, iy*MAP_CELL_HEIGHT+1
+			color := byte(0x1F)
+			if cell == selected {
+				color = 0x70
+			} else if boardId == World.Info.CurrentBoard {
+				color = 0x1E
+			}
+			VideoWriteText(sx, sy, color, fmt.Sprintf("%3d", boardId))
+			// East and south links, with the board there if any.
+			for _, dir := range []int{3, 1} {
+				next := TCoord{cell.X + NeighborDeltaX[dir], cell.Y + NeighborDeltaY[dir]}
+				nextId, nextOk := cells[next]
+				target := int16(links[boardId][dir])
+				there := nextOk && target == nextId
+				back := nextOk && int16(links[nextId][dir^1]) == boardId
+				backElsewhere := nextOk && links[nextId][dir^1] != 0 && !back
+				if dir == 3 {
+					editorDrawLink(sx+3, sy, true, there, back, target != 0 && !there, backElsewhere)
+				} else if iy < MAP_ROWS-1 {
+					editorDrawLink(sx+1, sy+1, false, there, back, target != 0 && !there, backElsewhere)
+				}
+			}
+		}
+	}
+	VideoWriteText(0, BOARD_HEIGHT-1, 0x0F, fmt.Sprintf("%-60s", fmt.Sprintf(" %d of %d boards linked to board %d",
+		len(places), len(links), cells[TCoord{0, 0}])))
+}
+
+func editorDrawWorldMapSidebar(links [][4]byte, boardId int16, ok bool) {
+	SidebarClear()
+	if ok {
+		VideoWriteText(63, 3, 0x1F, "Board "+Str(boardId))
+		VideoWriteText(63, 4, 0x1E, Copy(EditorGetBoardName(int(boardId), false), 1, 16))
+		for dir := 0; dir < 4; dir++ {
+			name := "-"
+			if target := links[boardId][dir]; target != 0 && int(target) < len(links) {
+				name = Str(target) + " " + EditorGetBoardName(int(target), false)
+			}
+			VideoWriteText(61, int16(6+dir), 0x1E, NeighborBoardStrs[dir][13:]+" "+Copy(name, 1, 16))
+		}
+	}
+	VideoWriteText(61, 12, 0x70, " NSWE ")
+	VideoWriteText(67, 12, 0x1F, " Set link")
+	VideoWriteText(61, 13, 0x30, " R ")
+	VideoWriteText(64, 13, 0x1F, " Link back")
+	VideoWriteText(61, 14, 0x70, " P ")
+	VideoWriteText(64, 14, 0x1F, " Passages")
+	VideoWriteText(61, 15, 0x30, " Enter ")
+	VideoWriteText(68, 15, 0x1F, " Go there")
+}
+
+// EditorWorldMap shows the boards around the current one, laid out by their
+// neighbor links, for the links to be changed. It returns the board, and
+// position if any, to go to, or -1.
+func EditorWorldMap() (boardId int16, pos TCoord, changed bool) {
+	var selected TCoord
+	root := World.Info.CurrentBoard
+	links := EditorNeighborLinks()
+	cells, places := EditorWorldMapLayout(links, root)
+	redraw := true
+	boardId = -1
+	for {
+		selectedId, ok := cells[selected]
+		if redraw {
+			editorDrawWorldMap(links, cells, places, selected)
+			editorDrawWorldMapSidebar(links, selectedId, ok)
+			redraw = false
+		}
+		Idle(IdleUntilFrame)
+		InputUpdate()
+		if InputDeltaX != 0 || InputDeltaY != 0 {
+			selected = TCoord{selected.X + InputDeltaX, selected.Y + InputDeltaY}
+			redraw = true
+			continue
+		}
+		switch UpCase(InputKeyPressed) {
+		case 'N', 'S', 'W', 'E':
+			if !ok {
+				continue
+			}
+			dir := map[byte]int{'N': 0, 'S': 1, 'W': 2, 'E': 3}[UpCase(InputKeyPressed)]
+			target := EditorSelectBoard(NeighborBoardStrs[dir], int16(links[selectedId][dir]), true)
+			if InputKeyPressed != KEY_ESCAPE && int(target) < len(World.BoardData) && target != selectedId {
+				EditorSetNeighborLink(selectedId, dir, byte(target))
+				changed = true
+			}
+		case 'R':
+			if !ok {
+				continue
+			}
+			if count := EditorMakeLinksReciprocal(selectedId); count > 0 {
+				changed = true
+			}
+		case 'P':
+			if passage, picked := EditorBrowsePassages(); picked {
+				return passage.Board, TCoord{passage.X, passage.Y}, changed
+			}
+		case KEY_ENTER:
+			if ok {
+				return selectedId, TCoord{}, changed
+			}
+			continue
+		case KEY_ESCAPE:
+			return
+		default:
+			continue
+		}
+		links = EditorNeighborLinks()
+		cells, places = EditorWorldMapLayout(links, root)
+		if p, ok := places[selectedId]; ok {
+			selected = p
+		}
+		redraw = true
+	}
+}
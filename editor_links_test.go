@@ -0,0 +1,61 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorLinks(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+	BoardClose()
+	for len(World.BoardData) < 4 {
+		World.BoardData = append(World.BoardData, World.BoardData[0])
+	}
+
+	// Board 1 has a blue passage to board 2, which leads back by a green one.
+	addPassage := func(boardId int16, x int16, color byte, dest byte) {
+		BoardChange(boardId)
+		template := StatTemplateDefault
+		template.P3 = dest
+		AddStat(x, 5, E_PASSAGE, int16(color), 0, template)
+	}
+	addPassage(1, 10, 0x1F, 2)
+	addPassage(2, 10, 0x2F, 1)
+	Board.Info.NeighborBoards[3] = 3
+	BoardChange(1)
+
+	passages := EditorCollectPassages()
+	if assert.Len(passages, 2) {
+		assert.Equal(int16(1), passages[0].Board)
+		assert.Equal("Blue", editorPassageColorName(passages[0].Color))
+		assert.False(passages[0].Matched)
+		assert.Equal(int16(2), passages[1].Board)
+		assert.False(passages[1].Matched)
+	}
+	addPassage(2, 20, 0x1F, 1)
+	BoardChange(1)
+	passages = EditorCollectPassages()
+	if assert.Len(passages, 3) {
+		assert.True(passages[0].Matched)
+	}
+
+	// Board 1 leads south to board 2, which leads east to board 3.
+	EditorSetNeighborLink(1, 1, 2)
+	links := EditorNeighborLinks()
+	assert.Equal(byte(3), links[2][3])
+	cells, places := EditorWorldMapLayout(links, 1)
+	assert.Len(places, 3)
+	assert.Equal(int16(3), cells[TCoord{1, 1}])
+
+	assert.Equal(1, EditorMakeLinksReciprocal(1))
+	assert.Equal(byte(1), EditorNeighborLinks()[2][0])
+	assert.Equal(0, EditorMakeLinksReciprocal(1))
+	assert.Equal(1, EditorMakeLinksReciprocal(2))
+	assert.Equal(byte(2), EditorNeighborLinks()[3][2])
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+}
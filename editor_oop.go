@@ -0,0 +1,288 @@
+//go:build editor
+
+package main
+
+import "strings"
+
+// Editor code highlighting - coloring ZZT-OOP, and finding the lines which
+// OopExecute would stop at with an error. The parsing follows OopExecute's,
+// one line at a time.
+
+const (
+	OOP_COLOR_TEXT      = 0x1E
+	OOP_COLOR_CENTERED  = 0x1F
+	OOP_COLOR_HYPERLINK = 0x1D
+	OOP_COLOR_NAME      = 0x1A
+	OOP_COLOR_LABEL     = 0x1B
+	OOP_COLOR_ZAPPED    = 0x13
+	OOP_COLOR_COMMENT   = 0x19
+	OOP_COLOR_COMMAND   = 0x1F
+	OOP_COLOR_ARGUMENT  = 0x17
+	OOP_COLOR_DIRECTION = 0x1A
+	OOP_COLOR_TARGET    = 0x1D
+	OOP_COLOR_ERROR     = 0x1C
+)
+
+type oopLineChecker struct {
+	line   string
+	pos    int
+	colors []byte
+	labels []string
+	err    string
+}
+
+// OopCodeLabels lists the labels of a program, upper case, as #SEND looks
+// for them. A label on the first line can not be sent to.
+func OopCodeLabels(lines []string) (labels []string) {
+	for i := 1; i < len(lines); i++ {
+		if Length(lines[i]) != 0 && lines[i][0] == ':' {
+			labels = append(labels, strings.ToUpper(lines[i][1:]))
+		}
+	}
+	return
+}
+
+// OopCheckLine colors a line of code, and returns the error OopExecute
+// would show for it, if any.
+func OopCheckLine(line string, labels []string) (colors []byte, err string) {
+	c := oopLineChecker{line: line, colors: make([]byte, len(line)), labels: labels}
+	for i := range c.colors {
+		c.colors[i] = OOP_COLOR_ARGUMENT
+	}
+	c.instruction()
+	return c.colors, c.err
+}
+
+func (c *oopLineChecker) peek() byte {
+	if c.pos < len(c.line) {
+		return c.line[c.pos]
+	}
+	return '\x00'
+}
+
+func (c *oopLineChecker) paint(from int, color byte) {
+	for i := from; i < c.pos; i++ {
+		c.colors[i] = color
+	}
+}
+
+// fail marks the rest of the line, starting at from, as wrong.
+func (c *oopLineChecker) fail(from int, message string) {
+	if Length(c.err) == 0 {
+		c.err = message
+	}
+	c.pos = len(c.line)
+	c.paint(from, OOP_COLOR_ERROR)
+}
+
+func (c *oopLineChecker) hasLabel(word string) bool {
+	for _, label := range c.labels {
+		if strings.HasPrefix(label, word) {
+			if len(label) == len(word) {
+				return true
+			}
+			if ch := label[len(word)]; (ch < 'A' || ch > 'Z') && ch != '_' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readWord reads a word as OopReadWord does.
+func (c *oopLineChecker) readWord() (word string, from int) {
+	var sb strings.Builder
+	for c.peek() == ' ' {
+		c.pos++
+	}
+	from = c.pos
+	ch := UpCase(c.peek())
+	if ch < '0' || ch > '9' {
+		for ch >= 'A' && ch <= 'Z' || ch == ':' || ch >= '0' && ch <= '9' || ch == '_' {
+			sb.WriteByte(ch)
+			c.pos++
+			ch = UpCase(c.peek())
+		}
+	}
+	return sb.String(), from
+}
+
+// readValue reads a number as OopReadValue does.
+func (c *oopLineChecker) readValue() {
+	for c.peek() == ' ' {
+		c.pos++
+	}
+	from := c.pos
+	for c.peek() >= '0' && c.peek() <= '9' {
+		c.pos++
+	}
+	c.paint(from, OOP_COLOR_ARGUMENT)
+}
+
+// direction follows OopParseDirection. idle is set for directions which
+// never move.
+func (c *oopLineChecker) direction(word string) (ok, idle bool) {
+	switch word {
+	case "I", "IDLE":
+		return true, true
+	case "N", "NORTH", "S", "SOUTH", "E", "EAST", "W", "WEST", "SEEK", "FLOW", "RND", "RNDNS", "RNDNE":
+		return true, false
+	case "CW", "CCW", "RNDP", "OPP":
+		word, _ = c.readWord()
+		return c.direction(word)
+	}
+	return false, false
+}
+
+func (c *oopLineChecker) readDirection() (ok, idle bool) {
+	word, from := c.readWord()
+	if ok, idle = c.direction(word); ok {
+		c.paint(from, OOP_COLOR_DIRECTION)
+	} else {
+		c.fail(from, "Bad direction")
+	}
+	return
+}
+
+// readTile follows OopParseTile.
+func (c *oopLineChecker) readTile() bool {
+	word, from := c.readWord()
+	for i := 1; i <= 7; i++ {
+		if word == OopStringToWord(ColorNames[i-1]) {
+			word, _ = c.readWord()
+			break
+		}
+	}
+	for i := 0; i <= MAX_ELEMENT; i++ {
+		if word == OopStringToWord(ElementDefs[i].Name) {
+			c.paint(from, OOP_COLOR_ARGUMENT)
+			return true
+		}
+	}
+	return false
+}
+
+// condition follows OopCheckCondition.
+func (c *oopLineChecker) condition() {
+	word, from := c.readWord()
+	c.paint(from, OOP_COLOR_ARGUMENT)
+	switch word {
+	case "NOT":
+		c.condition()
+	case "BLOCKED":
+		c.readDirection()
+	case "ANY":
+		if !c.readTile() {
+			c.fail(from, "Bad object kind")
+		}
+	}
+}
+
+// command follows OopExecute from its ReadCommand label on.
+func (c *oopLineChecker) command() {
+	word, from := c.readWord()
+	if word == "THEN" {
+		c.paint(from, OOP_COLOR_COMMAND)
+		word, from = c.readWord()
+	}
+	if Length(word) == 0 {
+		c.instruction()
+		return
+	}
+	c.paint(from, OOP_COLOR_COMMAND)
+	switch word {
+	case "GO", "WALK", "SHOOT", "THROWSTAR":
+		c.readDirection()
+	case "TRY":
+		if ok, _ := c.readDirection(); ok {
+			c.command()
+		}
+	case "SET", "CLEAR":
+		word, from = c.readWord()
+		c.paint(from, OOP_COLOR_ARGUMENT)
+	case "IF":
+		c.condition()
+		if Length(c.err) == 0 {
+			c.command()
+		}
+	case "GIVE", "TAKE":
+		word, from = c.readWord()
+		c.paint(from, OOP_COLOR_ARGUMENT)
+		switch word {
+		case "HEALTH", "AMMO", "GEMS", "TORCHES", "SCORE", "TIME":
+			c.readValue()
+			c.command()
+		}
+	case "END", "ENDGAME", "IDLE", "RESTART", "LOCK", "UNLOCK", "DIE", "PLAY", "CYCLE", "CHAR":
+	case "ZAP", "RESTORE", "SEND", "BIND":
+		word, from = c.readWord()
+		c.paint(from, OOP_COLOR_TARGET)
+	case "BECOME":
+		if !c.readTile() {
+			c.fail(from, "Bad #BECOME")
+		}
+	case "PUT":
+		if ok, idle := c.readDirection(); ok && (idle || !c.readTile()) {
+			c.fail(from, "Bad #PUT")
+		}
+	case "CHANGE":
+		if !c.readTile() || !c.readTile() {
+			c.fail(from, "Bad #CHANGE")
+		}
+	default:
+		if Pos(':', word) <= 0 && !c.hasLabel(word) {
+			c.fail(from, "Bad command "+word)
+		} else {
+			c.paint(from, OOP_COLOR_TARGET)
+		}
+	}
+}
+
+// instruction follows OopExecute from its ReadInstruction label on.
+func (c *oopLineChecker) instruction() {
+	from := c.pos
+	switch c.peek() {
+	case '\x00':
+		return
+	case ':':
+		c.pos = len(c.line)
+		c.paint(from, OOP_COLOR_LABEL)
+	case '\'':
+		// A comment made of a single word may be a zapped label.
+		c.pos++
+		if word, _ := c.readWord(); Length(word) != 0 && Length(strings.TrimSpace(c.line[c.pos:])) == 0 {
+			c.pos = len(c.line)
+			c.paint(from, OOP_COLOR_ZAPPED)
+		} else {
+			c.pos = len(c.line)
+			c.paint(from, OOP_COLOR_COMMENT)
+		}
+	case '@':
+		c.pos = len(c.line)
+		c.paint(from, OOP_COLOR_NAME)
+	case '/', '?':
+		c.pos++
+		if ok, _ := c.readDirection(); ok {
+			c.paint(from, OOP_COLOR_DIRECTION)
+			c.instruction()
+		}
+	case '#':
+		c.pos++
+		c.paint(from, OOP_COLOR_COMMAND)
+		c.command()
+	case '!':
+		c.pos = len(c.line)
+		c.paint(from, OOP_COLOR_CENTERED)
+		c.pos = from + strings.IndexByte(c.line[from:], ';') + 1
+		if c.pos == from {
+			c.pos = len(c.line)
+		}
+		c.paint(from, OOP_COLOR_HYPERLINK)
+	case '$':
+		c.pos = len(c.line)
+		c.paint(from, OOP_COLOR_CENTERED)
+	default:
+		c.pos = len(c.line)
+		c.paint(from, OOP_COLOR_TEXT)
+	}
+}
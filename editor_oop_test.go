@@ -0,0 +1,65 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOopCheckLine(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	labels := OopCodeLabels([]string{":first", "@name", ":touch", ":shot2"})
+	assert.Equal([]string{"TOUCH", "SHOT2"}, labels)
+
+	errors := map[string]string{
+		"Hello, world!":             "",
+		"#go n":                     "",
+		"#go nowhere":               "Bad direction",
+		"/n/s?cw rndp e#end":        "",
+		"/x":                        "Bad direction",
+		"#if blocked opp seek go n": "",
+		"#if any red lion then die": "",
+		"#if any goblin then die":   "Bad object kind",
+		"#if not alligned #foo":     "Bad command FOO",
+		"#give gems 5 #touch":       "",
+		"#take ammo 3 oops":         "Bad command OOPS",
+		"#become blue gem":          "",
+		"#become dragon":            "Bad #BECOME",
+		"#put s boulder":            "",
+		"#put i boulder":            "Bad #PUT",
+		"#put n dragon":             "Bad #PUT",
+		"#change red key blue door": "",
+		"#change red key dragon":    "Bad #CHANGE",
+		"#touch":                    "",
+		"#shot":                     "",
+		"#first":                    "Bad command FIRST",
+		"#others:touch":             "",
+		"#send nothing":             "",
+		"#play cdefg":               "",
+		"#try e #shoot w":           "",
+		"#try up":                   "Bad direction",
+		"'a comment, or two":        "",
+		"!touch;Hyperlink":          "",
+		"#":                         "",
+	}
+	for line, expected := range errors {
+		_, err := OopCheckLine(line, labels)
+		assert.Equal(expected, err, line)
+	}
+
+	colors, _ := OopCheckLine("#go n", labels)
+	assert.Equal([]byte{OOP_COLOR_COMMAND, OOP_COLOR_COMMAND, OOP_COLOR_COMMAND, OOP_COLOR_ARGUMENT, OOP_COLOR_DIRECTION}, colors)
+	colors, _ = OopCheckLine("'touch", labels)
+	assert.Equal(byte(OOP_COLOR_ZAPPED), colors[0])
+	colors, _ = OopCheckLine("'not a label", labels)
+	assert.Equal(byte(OOP_COLOR_COMMENT), colors[0])
+	colors, _ = OopCheckLine("!a;b", labels)
+	assert.Equal([]byte{OOP_COLOR_HYPERLINK, OOP_COLOR_HYPERLINK, OOP_COLOR_HYPERLINK, OOP_COLOR_CENTERED}, colors)
+	colors, _ = OopCheckLine("#send b:go", labels)
+	assert.Equal(byte(OOP_COLOR_TARGET), colors[9])
+}
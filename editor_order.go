@@ -0,0 +1,91 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Editor tick order - stats are updated in the order they are stored in, so
+// the order decides who moves first. Centipedes refer to each other by index
+// through Leader and Follower, which are rewritten as stats are moved. Stats
+// bound with #BIND share their code in memory, and are written out as
+// references to the first of them whichever order they end up in.
+
+// EditorSwapStats swaps two stats, other than the player, in tick order.
+func EditorSwapStats(a, b int16) {
+	if a == b || a <= 0 || b <= 0 || a > Board.Stats.Count || b > Board.Stats.Count {
+		return
+	}
+	statA, statB := Board.Stats.At(a), Board.Stats.At(b)
+	*statA, *statB = *statB, *statA
+	remap := func(id int16) int16 {
+		if id == a {
+			return b
+		} else if id == b {
+			return a
+		}
+		return id
+	}
+	for i := int16(0); i <= Board.Stats.Count; i++ {
+		stat := Board.Stats.At(i)
+		stat.Leader = remap(stat.Leader)
+		stat.Follower = remap(stat.Follower)
+	}
+}
+
+func editorTickOrderLine(statId int16) string {
+	stat := Board.Stats.At(statId)
+	element := Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element
+	return Copy(fmt.Sprintf("%3d %-14s %2d,%-2d %s", statId, ElementDefs[element].Name, stat.X, stat.Y, StatObjectName(stat)),
+		1, TextWindowWidth-8)
+}
+
+// EditorEditTickOrder lists the stats of the current board in tick order,
+// and lets them be moved up and down. N toggles showing the numbers on the
+// board. It returns the stat picked with Enter, or -1.
+func EditorEditTickOrder(showNumbers *bool) (statId int16, changed bool) {
+	const actionKeys = "-+=N"
+	var textWindow TTextWindowState
+	statId = 1
+	for {
+		textWindow.Init()
+		textWindow.Title = "Tick order (-+:Move N:Numbers)"
+		textWindow.Selectable = true
+		textWindow.ExitKeys = actionKeys
+		textWindow.LinePos = int(Min(statId, Board.Stats.Count)) + 1
+		for i := int16(0); i <= Board.Stats.Count; i++ {
+			textWindow.Append(editorTickOrderLine(i))
+		}
+		textWindow.DrawOpen()
+		textWindow.Select(false, false)
+		textWindow.DrawClose()
+		statId = int16(textWindow.LinePos - 1)
+		if TextWindowRejected {
+			return -1, changed
+		} else if strings.IndexByte(actionKeys, UpCase(InputKeyPressed)) < 0 {
+			return
+		}
+		switch UpCase(InputKeyPressed) {
+		case '-':
+			if statId > 1 {
+				EditorSwapStats(statId, statId-1)
+				statId--
+				changed = true
+			}
+		case '+', '=':
+			if statId > 0 && statId < Board.Stats.Count {
+				EditorSwapStats(statId, statId+1)
+				statId++
+				changed = true
+			}
+		case 'N':
+			*showNumbers = !*showNumbers
+			TransitionDrawToBoard()
+			if *showNumbers {
+				EditorDrawStatLabels(true, false)
+			}
+		}
+	}
+}
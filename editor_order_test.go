@@ -0,0 +1,50 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorSwapStats(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	// A centipede head with two segments, then two objects bound together.
+	for i := int16(1); i <= 3; i++ {
+		AddStat(i, 1, E_CENTIPEDE_SEGMENT, 0x09, 2, StatTemplateDefault)
+	}
+	Board.Stats.At(1).Follower = 2
+	Board.Stats.At(2).Leader = 1
+	Board.Stats.At(2).Follower = 3
+	Board.Stats.At(3).Leader = 2
+	data := []byte("@door\r#end\r")
+	for i := int16(1); i <= 2; i++ {
+		AddStat(i, 2, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+		Board.Stats.At(3 + i).Data = &data
+		Board.Stats.At(3 + i).DataLen = int16(len(data))
+	}
+
+	EditorSwapStats(1, 2)
+	assert.Equal(byte(2), Board.Stats.At(1).X)
+	assert.Equal(int16(2), Board.Stats.At(1).Leader)
+	assert.Equal(int16(3), Board.Stats.At(1).Follower)
+	assert.Equal(int16(-1), Board.Stats.At(2).Leader)
+	assert.Equal(int16(1), Board.Stats.At(2).Follower)
+	assert.Equal(int16(1), Board.Stats.At(3).Leader)
+
+	// The player stays first.
+	EditorSwapStats(0, 1)
+	assert.Equal(byte(2), Board.Stats.At(1).X)
+
+	// Bound code survives the second object moving ahead of the first.
+	EditorSwapStats(4, 5)
+	BoardClose()
+	BoardOpen(World.Info.CurrentBoard)
+	assert.Equal(Board.Stats.At(4).Data, Board.Stats.At(5).Data)
+	assert.Equal("door", StatObjectName(Board.Stats.At(4)))
+	assert.Equal(int16(len(data)), Board.Stats.At(4).DataLen)
+}
@@ -0,0 +1,171 @@
+//go:build editor
+
+package main
+
+// Editor overlays - ways of looking at the board which are drawn over it,
+// leaving the tiles as they are. They are drawn again whenever the editor is
+// idle, over whatever was drawn since.
+
+type TEditorOverlays struct {
+	HiddenWalls bool // fake walls and invisible walls stand out
+	StatNumbers bool
+	StatCycles  bool
+	Code        bool // objects with code, colored by the code they share
+	Torch       bool // the reach of a torch lit at the cursor
+	Dark        bool // the board as the player sees it, if it is dark
+
+	torchX, torchY int16 // where the torch was last drawn
+}
+
+var editorOverlayNames = [...]string{
+	"Fake and invisible walls",
+	"Stat numbers",
+	"Stat cycles",
+	"Objects with code, by #BIND",
+	"Torch reach at the cursor",
+	"Darkness, as the player sees it",
+}
+
+func (o *TEditorOverlays) flags() [len(editorOverlayNames)]*bool {
+	return [...]*bool{&o.HiddenWalls, &o.StatNumbers, &o.StatCycles, &o.Code, &o.Torch, &o.Dark}
+}
+
+func (o *TEditorOverlays) Any() bool {
+	for _, flag := range o.flags() {
+		if *flag {
+			return true
+		}
+	}
+	return false
+}
+
+func editorInTorchReach(x, y, torchX, torchY int16) bool {
+	return Sqr(torchX-x)+Sqr(torchY-y)*2 < TORCH_DIST_SQR
+}
+
+// editorSetCellColor changes the color of a character on the screen,
+// keeping the character.
+func editorSetCellColor(x, y int16, color func(old byte) byte) {
+	var cell []byte
+	VideoMove(x-1, y-1, 1, &cell, false)
+	if len(cell) >= 2 {
+		cell[1] = color(cell[1])
+		VideoMove(x-1, y-1, 1, &cell, true)
+	}
+}
+
+// EditorDrawStatLabels writes the number and/or cycle of every stat over the
+// board, on top of the stat's tile and, for longer labels, the tiles to its
+// right.
+func EditorDrawStatLabels(numbers, cycles bool) {
+	for i := int16(0); i <= Board.Stats.Count; i++ {
+		stat := Board.Stats.At(i)
+		if stat.X < 1 || stat.X > BOARD_WIDTH || stat.Y < 1 || stat.Y > BOARD_HEIGHT {
+			continue
+		}
+		var label string
+		color := byte(0x4F)
+		if numbers && cycles {
+			label = Str(i) + "/" + Str(stat.Cycle)
+		} else if numbers {
+			label = Str(i)
+		} else {
+			label = Str(stat.Cycle)
+			color = 0x2F
+		}
+		VideoWriteText(int16(stat.X)-1, int16(stat.Y)-1, color, Copy(label, 1, BOARD_WIDTH-int16(stat.X)+1))
+	}
+}
+
+// EditorDrawOverlays draws the overlays turned on.
+func EditorDrawOverlays(o *TEditorOverlays, cursorX, cursorY int16) {
+	var ix, iy int16
+	// Tiles the torch has moved away from are drawn as they are first.
+	if o.torchX > 0 && (!o.Torch || o.torchX != cursorX || o.torchY != cursorY) {
+		for iy = 1; iy <= BOARD_HEIGHT; iy++ {
+			for ix = 1; ix <= BOARD_WIDTH; ix++ {
+				if editorInTorchReach(ix, iy, o.torchX, o.torchY) {
+					BoardDrawTile(ix, iy)
+				}
+			}
+		}
+		o.torchX = 0
+	}
+
+	dark := o.Dark && Board.Info.IsDark
+	for iy = 1; iy <= BOARD_HEIGHT; iy++ {
+		for ix = 1; ix <= BOARD_WIDTH; ix++ {
+			tile := Board.Tiles.Get(ix, iy)
+			lit := o.Torch && editorInTorchReach(ix, iy, cursorX, cursorY)
+			if dark && !ElementDefs[tile.Element].VisibleInDark && !lit {
+				VideoWriteText(ix-1, iy-1, 0x07, "\xb0")
+				continue
+			}
+			if lit {
+				BoardDrawTile(ix, iy)
+				if !dark {
+					editorSetCellColor(ix, iy, func(old byte) byte { return old&0x0F | 0x60 })
+				}
+			}
+			if o.HiddenWalls {
+				switch tile.Element {
+				case E_FAKE:
+					VideoWriteText(ix-1, iy-1, tile.Color|0x80, "\xb1")
+				case E_INVISIBLE:
+					VideoWriteText(ix-1, iy-1, tile.Color|0x80, "\xb0")
+				}
+			}
+		}
+	}
+	if o.Torch {
+		o.torchX, o.torchY = cursorX, cursorY
+	}
+
+	if o.Code {
+		// Objects sharing code get the same background.
+		groups := make(map[*[]byte]byte)
+		for i := int16(1); i <= Board.Stats.Count; i++ {
+			stat := Board.Stats.At(i)
+			if stat.Data == nil || stat.DataLen <= 0 || stat.X < 1 || stat.X > BOARD_WIDTH || stat.Y < 1 || stat.Y > BOARD_HEIGHT {
+				continue
+			}
+			bg, ok := groups[stat.Data]
+			if !ok {
+				bg = byte(len(groups)%6 + 1)
+				groups[stat.Data] = bg
+			}
+			editorSetCellColor(int16(stat.X), int16(stat.Y), func(old byte) byte { return old&0x0F | bg<<4 })
+		}
+	}
+
+	if o.StatNumbers || o.StatCycles {
+		EditorDrawStatLabels(o.StatNumbers, o.StatCycles)
+	}
+}
+
+// EditorOverlayMenu lists the overlays, for Enter to turn them on and off.
+func EditorOverlayMenu(o *TEditorOverlays) {
+	var state TTextWindowState
+	state.Init()
+	state.Title = "Overlays"
+	state.Selectable = true
+	state.DrawOpen()
+	for {
+		state.Lines = make([]string, 0)
+		for i, flag := range o.flags() {
+			mark := "[ ] "
+			if *flag {
+				mark = "[\xfb] "
+			}
+			state.Append(mark + editorOverlayNames[i])
+		}
+		state.Select(false, false)
+		if InputKeyPressed != KEY_ENTER {
+			break
+		}
+		flag := o.flags()[state.LinePos-1]
+		*flag = !*flag
+	}
+	state.DrawClose()
+	InputKeyPressed = '\x00'
+}
@@ -0,0 +1,70 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorDrawOverlays(t *testing.T) {
+	assert := assert.New(t)
+	dummy := NewDummyPlatform()
+	testPlatform(t, dummy)
+	WorldCreate()
+	InitElementsEditor()
+	defer InitElementsGame()
+	cell := func(x, y int16) (byte, byte) {
+		return dummy.textBuffer[y-1][(x-1)*2], dummy.textBuffer[y-1][(x-1)*2+1]
+	}
+
+	Board.Tiles.Set(5, 5, TTile{Element: E_FAKE, Color: 0x0E})
+	Board.Tiles.Set(6, 5, TTile{Element: E_NORMAL, Color: 0x0E})
+	data := []byte("#end\r")
+	for i := int16(1); i <= 2; i++ {
+		AddStat(i*10, 10, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+		Board.Stats.At(i).Data = &data
+		Board.Stats.At(i).DataLen = int16(len(data))
+	}
+	for iy := int16(1); iy <= BOARD_HEIGHT; iy++ {
+		for ix := int16(1); ix <= BOARD_WIDTH; ix++ {
+			BoardDrawTile(ix, iy)
+		}
+	}
+	ch, _ := cell(5, 5)
+	assert.Equal(byte('\xb2'), ch)
+
+	o := &TEditorOverlays{HiddenWalls: true, Code: true}
+	assert.True(o.Any())
+	EditorDrawOverlays(o, 30, 12)
+	ch, color := cell(5, 5)
+	assert.Equal(byte('\xb1'), ch)
+	assert.Equal(byte(0x8E), color)
+	ch, _ = cell(6, 5)
+	assert.Equal(byte('\xb2'), ch)
+	_, color1 := cell(10, 10)
+	_, color2 := cell(20, 10)
+	assert.NotZero(color1 & 0xF0)
+	assert.Equal(color1&0xF0, color2&0xF0)
+
+	o.StatNumbers = true
+	EditorDrawOverlays(o, 30, 12)
+	ch, _ = cell(20, 10)
+	assert.Equal(byte('2'), ch)
+
+	// A dark board, lit around the cursor only.
+	Board.Info.IsDark = true
+	o = &TEditorOverlays{Dark: true, Torch: true}
+	EditorDrawOverlays(o, 30, 12)
+	ch, _ = cell(6, 5)
+	assert.Equal(byte('\xb0'), ch)
+	ch, _ = cell(31, 12)
+	assert.Equal(byte(' '), ch)
+	EditorDrawOverlays(o, 6, 5)
+	ch, _ = cell(6, 5)
+	assert.Equal(byte('\xb2'), ch)
+	ch, color = cell(31, 12)
+	assert.Equal(byte('\xb0'), ch)
+	assert.Equal(byte(0x07), color)
+}
@@ -0,0 +1,35 @@
+//go:build editor
+
+package main
+
+// Editor test play - playing the world as it is being edited, starting on
+// the current board, and going back to the editor as if nothing happened.
+
+// EditorTestPlay plays the current board with the player moved to x, y,
+// until the game is quit or lost. The world, boards included, is then put
+// back the way it was. Test games do not make it to the high score list.
+func EditorTestPlay(x, y int16) {
+	snapshot := EditorSnapshotTake()
+	info := World.Info
+	stateElement, paused := GameStateElement, GamePaused
+	defer func() {
+		GameTestPlaying = false
+		snapshot.Restore()
+		World.Info = info
+		GameStateElement, GamePaused = stateElement, paused
+		InitElementsEditor()
+		InputKeyPressed = '\x00'
+	}()
+
+	if x != int16(Board.Stats.At(0).X) || y != int16(Board.Stats.At(0).Y) {
+		if BoardPrepareTileForPlacement(x, y) {
+			MoveStat(0, x, y)
+		}
+	}
+	InitElementsGame()
+	GameTestPlaying = true
+	GameStateElement = E_PLAYER
+	GamePaused = true
+	BoardEnter()
+	GamePlayLoop(true)
+}
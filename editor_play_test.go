@@ -0,0 +1,64 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowKeysPlatform hands out a key every few frames, as a player would.
+type slowKeysPlatform struct {
+	DummyPlatform
+	keys  []byte
+	idles int
+}
+
+func (f *slowKeysPlatform) Video() PlatformVideo { return f }
+func (f *slowKeysPlatform) Input() PlatformInput { return f }
+func (f *slowKeysPlatform) Timer() PlatformTimer { return f }
+
+func (f *slowKeysPlatform) KeyPressed() bool {
+	return len(f.keys) > 0 && f.idles >= 20
+}
+
+func (f *slowKeysPlatform) ReadKey() byte {
+	k := f.keys[0]
+	f.keys = f.keys[1:]
+	f.idles = 0
+	return k
+}
+
+func (f *slowKeysPlatform) Idle(mode IdleMode) {
+	f.DummyPlatform.Idle(mode)
+	f.idles++
+}
+
+func TestEditorTestPlay(t *testing.T) {
+	assert := assert.New(t)
+	fake := &slowKeysPlatform{DummyPlatform: *NewDummyPlatform()}
+	testPlatform(t, fake)
+	WorldCreate()
+	InitElementsEditor()
+	GameStateElement = E_MONITOR
+	Board.Tiles.Set(11, 10, TTile{Element: E_GEM, Color: 0x0A})
+	playerX, playerY := Board.Stats.At(0).X, Board.Stats.At(0).Y
+	before := EditorSnapshotTake()
+	info := World.Info
+
+	// Step onto the gem, then quit.
+	fake.keys = []byte{'6', 'Q', 'Y'}
+	EditorTestPlay(10, 10)
+	assert.Empty(fake.keys)
+
+	after := EditorSnapshotTake()
+	assert.True(before.Equal(&after))
+	assert.Equal(info, World.Info)
+	assert.Equal(playerX, Board.Stats.At(0).X)
+	assert.Equal(playerY, Board.Stats.At(0).Y)
+	assert.Equal(byte(E_GEM), Board.Tiles.Get(11, 10).Element)
+	assert.Equal(int16(E_MONITOR), GameStateElement)
+	assert.False(GameTestPlaying)
+	assert.True(ForceDarknessOff)
+}
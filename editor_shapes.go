@@ -0,0 +1,105 @@
+//go:build editor
+
+package main
+
+// Editor shapes - the tiles covered by the fill and shape tools
+
+const (
+	SHAPE_LINE = iota
+	SHAPE_BOX
+	SHAPE_OVAL
+)
+
+// EditorShapeLine returns the tiles on a line between two points.
+func EditorShapeLine(x1, y1, x2, y2 int16) (points []TCoord) {
+	dx := Abs(x2 - x1)
+	dy := -Abs(y2 - y1)
+	sx, sy := int16(1), int16(1)
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		points = append(points, TCoord{x1, y1})
+		if x1 == x2 && y1 == y2 {
+			break
+		}
+		e2 := e * 2
+		if e2 >= dy {
+			e += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y1 += sy
+		}
+	}
+	return
+}
+
+// EditorShapeBox returns the tiles on the edge of a rectangle, or in all of
+// it if filled.
+func EditorShapeBox(x1, y1, x2, y2 int16, filled bool) (points []TCoord) {
+	var ix, iy int16
+	x1, y1, x2, y2 = editorNormalizeRect(x1, y1, x2, y2)
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if filled || ix == x1 || ix == x2 || iy == y1 || iy == y2 {
+				points = append(points, TCoord{ix, iy})
+			}
+		}
+	}
+	return
+}
+
+// EditorShapeOval returns the tiles on the edge of the ellipse fitting in a
+// rectangle, or in all of it if filled.
+func EditorShapeOval(x1, y1, x2, y2 int16, filled bool) (points []TCoord) {
+	var ix, iy int16
+	x1, y1, x2, y2 = editorNormalizeRect(x1, y1, x2, y2)
+	cx := float64(x1+x2) / 2
+	cy := float64(y1+y2) / 2
+	rx := float64(x2-x1+1) / 2
+	ry := float64(y2-y1+1) / 2
+	inside := func(x, y int16) bool {
+		fx := (float64(x) - cx) / rx
+		fy := (float64(y) - cy) / ry
+		return fx*fx+fy*fy <= 1
+	}
+	for iy = y1; iy <= y2; iy++ {
+		for ix = x1; ix <= x2; ix++ {
+			if inside(ix, iy) && (filled || !inside(ix-1, iy) || !inside(ix+1, iy) || !inside(ix, iy-1) || !inside(ix, iy+1)) {
+				points = append(points, TCoord{ix, iy})
+			}
+		}
+	}
+	return
+}
+
+// EditorFloodRegion returns the tiles connected to x, y which have the same
+// element, and the same color too if byColor is set. Empty tiles match
+// regardless of their color.
+func EditorFloodRegion(x, y int16, byColor bool) (points []TCoord) {
+	var visited [BOARD_WIDTH + 2][BOARD_HEIGHT + 2]bool
+	from := Board.Tiles.Get(x, y)
+	visited[x][y] = true
+	points = append(points, TCoord{x, y})
+	for i := 0; i < len(points); i++ {
+		for dir := 0; dir <= 3; dir++ {
+			nx := points[i].X + NeighborDeltaX[dir]
+			ny := points[i].Y + NeighborDeltaY[dir]
+			if nx < 1 || ny < 1 || nx > BOARD_WIDTH || ny > BOARD_HEIGHT || visited[nx][ny] {
+				continue
+			}
+			tile := Board.Tiles.Get(nx, ny)
+			if tile.Element == from.Element && (!byColor || from.Element == E_EMPTY || tile.Color == from.Color) {
+				visited[nx][ny] = true
+				points = append(points, TCoord{nx, ny})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,44 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorShapes(t *testing.T) {
+	assert := assert.New(t)
+
+	line := EditorShapeLine(5, 2, 1, 4)
+	assert.Equal(TCoord{5, 2}, line[0])
+	assert.Equal(TCoord{1, 4}, line[len(line)-1])
+	assert.Equal(5, len(line))
+
+	assert.Equal(8, len(EditorShapeBox(3, 3, 1, 1, false)))
+	assert.Equal(9, len(EditorShapeBox(1, 1, 3, 3, true)))
+
+	assert.Equal([]TCoord{{4, 4}}, EditorShapeOval(4, 4, 4, 4, false))
+	oval := EditorShapeOval(1, 1, 7, 5, false)
+	assert.Contains(oval, TCoord{4, 1})
+	assert.Contains(oval, TCoord{1, 3})
+	assert.NotContains(oval, TCoord{1, 1})
+	assert.NotContains(oval, TCoord{4, 3})
+	assert.Contains(EditorShapeOval(1, 1, 7, 5, true), TCoord{4, 3})
+}
+
+func TestEditorFloodRegion(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	for iy := int16(1); iy <= BOARD_HEIGHT; iy++ {
+		Board.Tiles.Set(10, iy, TTile{Element: E_SOLID, Color: 0x0E})
+	}
+	Board.Tiles.Set(10, 5, TTile{Element: E_SOLID, Color: 0x0C})
+	assert.Equal(BOARD_HEIGHT, len(EditorFloodRegion(10, 1, false)))
+	assert.Equal(4, len(EditorFloodRegion(10, 1, true)))
+	// The empty tiles between the border and the wall
+	assert.Equal(8*(BOARD_HEIGHT-2), len(EditorFloodRegion(2, 2, false)))
+}
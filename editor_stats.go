@@ -0,0 +1,166 @@
+//go:build editor
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Editor stat browser - every stat in the world, in one list
+
+const (
+	STAT_SORT_BOARD = iota
+	STAT_SORT_ELEMENT
+	STAT_SORT_NAME
+	STAT_SORT_CODE
+	STAT_SORT_CYCLE
+	STAT_SORT_COUNT
+)
+
+var EditorStatSortNames = [STAT_SORT_COUNT]string{"board", "element", "name", "code length", "cycle"}
+
+type TEditorStatEntry struct {
+	Board     int16
+	BoardName string
+	StatId    int16
+	X, Y      int16
+	Element   byte
+	Name      string
+	CodeLen   int16
+	Cycle     int16
+}
+
+// StatObjectName returns the @name an object's code starts with, if any.
+func StatObjectName(stat *TStat) string {
+	if stat.Data == nil || stat.DataLen <= 0 || (*stat.Data)[0] != '@' {
+		return ""
+	}
+	code := (*stat.Data)[1:stat.DataLen]
+	if i := strings.IndexByte(string(code), KEY_ENTER); i >= 0 {
+		code = code[:i]
+	}
+	return string(code)
+}
+
+// EditorCollectStats lists the stats on every board.
+func EditorCollectStats() (entries []TEditorStatEntry) {
+	currentBoard := World.Info.CurrentBoard
+	BoardClose()
+	for i := int16(0); i < int16(len(World.BoardData)); i++ {
+		BoardOpen(i)
+		for j := int16(0); j <= Board.Stats.Count; j++ {
+			stat := Board.Stats.At(j)
+			entries = append(entries, TEditorStatEntry{
+				Board:     i,
+				BoardName: Board.Name,
+				StatId:    j,
+				X:         int16(stat.X),
+				Y:         int16(stat.Y),
+				Element:   Board.Tiles.Get(int16(stat.X), int16(stat.Y)).Element,
+				Name:      StatObjectName(stat),
+				CodeLen:   stat.DataLen,
+				Cycle:     stat.Cycle,
+			})
+		}
+	}
+	BoardOpen(currentBoard)
+	return
+}
+
+// EditorFilterStats keeps the entries whose element, @name or board name
+// contains filter, ignoring case.
+func EditorFilterStats(entries []TEditorStatEntry, filter string) (filtered []TEditorStatEntry) {
+	filter = strings.ToUpper(filter)
+	for _, e := range entries {
+		if strings.Contains(strings.ToUpper(ElementDefs[e.Element].Name), filter) ||
+			strings.Contains(strings.ToUpper(e.Name), filter) ||
+			strings.Contains(strings.ToUpper(e.BoardName), filter) {
+			filtered = append(filtered, e)
+		}
+	}
+	return
+}
+
+// EditorSortStats sorts the entries, in board order among equal ones.
+func EditorSortStats(entries []TEditorStatEntry, sortBy int) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		switch sortBy {
+		case STAT_SORT_ELEMENT:
+			if ElementDefs[a.Element].Name != ElementDefs[b.Element].Name {
+				return ElementDefs[a.Element].Name < ElementDefs[b.Element].Name
+			}
+		case STAT_SORT_NAME:
+			if strings.ToUpper(a.Name) != strings.ToUpper(b.Name) {
+				return strings.ToUpper(a.Name) < strings.ToUpper(b.Name)
+			}
+		case STAT_SORT_CODE:
+			if a.CodeLen != b.CodeLen {
+				return a.CodeLen > b.CodeLen
+			}
+		case STAT_SORT_CYCLE:
+			if a.Cycle != b.Cycle {
+				return a.Cycle < b.Cycle
+			}
+		}
+		if a.Board != b.Board {
+			return a.Board < b.Board
+		}
+		return a.StatId < b.StatId
+	})
+}
+
+func editorStatEntryLine(e *TEditorStatEntry) string {
+	return fmt.Sprintf("%3d %2d,%-2d %-10.10s %-11.11s %4d %3d",
+		e.Board, e.X, e.Y, ElementDefs[e.Element].Name, e.Name, e.CodeLen, e.Cycle)
+}
+
+// EditorBrowseStats shows the stat list, with S changing the sort order and
+// F the filter. It returns false if nothing was picked.
+func EditorBrowseStats(sortBy *int, filter *string) (entry TEditorStatEntry, ok bool) {
+	var textWindow TTextWindowState
+	all := EditorCollectStats()
+	linePos := 2
+	for {
+		entries := all
+		if Length(*filter) != 0 {
+			entries = EditorFilterStats(entries, *filter)
+		}
+		EditorSortStats(entries, *sortBy)
+
+		textWindow.Init()
+		textWindow.Title = "World stats by " + EditorStatSortNames[*sortBy] + " (S:Sort F:Filter)"
+		textWindow.Selectable = true
+		textWindow.ExitKeys = "SF"
+		textWindow.Append(":; Bd X,Y   Element    Name        Code Cyc")
+		for i := range entries {
+			textWindow.Append(editorStatEntryLine(&entries[i]))
+		}
+		if len(entries) == 0 {
+			textWindow.Append("$No stats match \"" + *filter + "\".")
+		}
+		textWindow.LinePos = Min(linePos, len(textWindow.Lines))
+		textWindow.DrawOpen()
+		textWindow.Select(false, false)
+		textWindow.DrawClose()
+		linePos = textWindow.LinePos
+		if TextWindowRejected {
+			return
+		}
+		switch UpCase(InputKeyPressed) {
+		case 'S':
+			*sortBy = (*sortBy + 1) % STAT_SORT_COUNT
+			linePos = 2
+		case 'F':
+			SidebarPromptString("Filter:", "", filter, PROMPT_ANY)
+			linePos = 2
+		default:
+			if linePos >= 2 && linePos-2 < len(entries) {
+				return entries[linePos-2], true
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorStats(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	addObject := func(x, y int16, code string) {
+		data := []byte(code)
+		AddStat(x, y, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+		stat := Board.Stats.At(Board.Stats.Count)
+		stat.Data = &data
+		stat.DataLen = int16(len(data))
+	}
+	addObject(10, 5, "@Zed\r#end\r")
+	BoardClose()
+	World.BoardData = append(World.BoardData, nil)
+	World.Info.CurrentBoard = 1
+	BoardCreate()
+	Board.Name = "Cave"
+	addObject(20, 6, "@alpha\r")
+	addObject(21, 6, "#end\r")
+	BoardClose()
+	BoardOpen(0)
+
+	assert.Equal("Zed", StatObjectName(Board.Stats.At(1)))
+	assert.Equal("", StatObjectName(Board.Stats.At(0)))
+
+	entries := EditorCollectStats()
+	assert.Equal(int16(0), World.Info.CurrentBoard)
+	assert.Equal(5, len(entries))
+	assert.Equal(int16(1), entries[3].Board)
+	assert.Equal("alpha", entries[3].Name)
+	assert.Equal(int16(7), entries[3].CodeLen)
+
+	assert.Equal(3, len(EditorFilterStats(entries, "CAVE")))
+	assert.Equal(1, len(EditorFilterStats(entries, "zed")))
+	assert.Equal(3, len(EditorFilterStats(entries, "object")))
+
+	EditorSortStats(entries, STAT_SORT_NAME)
+	assert.Equal("alpha", entries[3].Name)
+	assert.Equal("Zed", entries[4].Name)
+	EditorSortStats(entries, STAT_SORT_CODE)
+	assert.Equal("Zed", entries[0].Name)
+	EditorSortStats(entries, STAT_SORT_BOARD)
+	assert.Equal(int16(0), entries[0].Board)
+	assert.Equal(int16(1), entries[1].StatId)
+}
@@ -0,0 +1,139 @@
+//go:build editor
+
+package main
+
+import (
+	"bytes"
+	"os"
+)
+
+// Editor undo history
+//
+// Every editing step (a key's worth of changes, or a whole drawing/text
+// entry stroke) is recorded as a snapshot of the world's boards taken right
+// before it. The current board is serialized as in BoardClose; the other
+// boards are already serialized and never modified in place, so they are
+// shared between snapshots.
+
+type (
+	TEditorSnapshot struct {
+		BoardData    [][]byte
+		CurrentBoard int16
+	}
+	TEditorHistory struct {
+		Depth int
+		// Changes goes up whenever the world may have been changed, even
+		// with the history off, so that what is worked out from it is only
+		// worked out again then.
+		Changes int
+		undo    []TEditorSnapshot
+		redo    []TEditorSnapshot
+		pending *TEditorSnapshot
+		open    bool
+	}
+)
+
+// EditorUndoDepth is the number of steps kept; /U:<depth> overrides it,
+// /U:0 turns the history off.
+var EditorUndoDepth = 64
+
+func EditorUndoDepthArgument() int {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && UpCase(pArg[1]) == 'U' && pArg[2] == ':' {
+			return Val(pArg[3:])
+		}
+	}
+	return EditorUndoDepth
+}
+
+func EditorSnapshotTake() (s TEditorSnapshot) {
+	BoardClose()
+	s.BoardData = make([][]byte, len(World.BoardData))
+	copy(s.BoardData, World.BoardData)
+	s.CurrentBoard = World.Info.CurrentBoard
+	return
+}
+
+func (s *TEditorSnapshot) Equal(o *TEditorSnapshot) bool {
+	if s.CurrentBoard != o.CurrentBoard || len(s.BoardData) != len(o.BoardData) {
+		return false
+	}
+	for i := range s.BoardData {
+		if !bytes.Equal(s.BoardData[i], o.BoardData[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *TEditorSnapshot) Restore() {
+	World.BoardData = make([][]byte, len(s.BoardData))
+	copy(World.BoardData, s.BoardData)
+	BoardOpen(s.CurrentBoard)
+}
+
+func NewEditorHistory(depth int) *TEditorHistory {
+	return &TEditorHistory{Depth: depth}
+}
+
+// Begin is called before the world is changed. Only the first call of each
+// step takes a snapshot.
+func (h *TEditorHistory) Begin() {
+	h.Changes++
+	h.open = true
+	if h.Depth > 0 && h.pending == nil {
+		s := EditorSnapshotTake()
+		h.pending = &s
+	}
+}
+
+// End finishes the current step, recording it if anything was changed.
+func (h *TEditorHistory) End() {
+	if h.open {
+		// Whatever was changed since Begin counts too.
+		h.Changes++
+		h.open = false
+	}
+	if h.pending == nil {
+		return
+	}
+	current := EditorSnapshotTake()
+	if !h.pending.Equal(&current) {
+		h.undo = append(h.undo, *h.pending)
+		if len(h.undo) > h.Depth {
+			h.undo = h.undo[len(h.undo)-h.Depth:]
+		}
+		h.redo = nil
+	}
+	h.pending = nil
+}
+
+func (h *TEditorHistory) Clear() {
+	h.Changes++
+	h.open = false
+	h.undo = nil
+	h.redo = nil
+	h.pending = nil
+}
+
+func (h *TEditorHistory) step(from, to *[]TEditorSnapshot) bool {
+	h.End()
+	if len(*from) == 0 {
+		return false
+	}
+	*to = append(*to, EditorSnapshotTake())
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	s.Restore()
+	h.Changes++
+	return true
+}
+
+func (h *TEditorHistory) Undo() bool {
+	return h.step(&h.undo, &h.redo)
+}
+
+func (h *TEditorHistory) Redo() bool {
+	return h.step(&h.redo, &h.undo)
+}
@@ -0,0 +1,66 @@
+//go:build editor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorHistory(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	WorldCreate()
+
+	h := NewEditorHistory(2)
+	for i := int16(1); i <= 3; i++ {
+		h.Begin()
+		Board.Tiles.Set(i+1, 2, TTile{Element: E_SOLID, Color: 0x0E})
+		h.End()
+	}
+	// A step which changes nothing is not recorded.
+	h.Begin()
+	h.End()
+
+	assert.True(h.Undo())
+	assert.Equal(byte(E_EMPTY), Board.Tiles.Get(4, 2).Element)
+	assert.Equal(byte(E_SOLID), Board.Tiles.Get(3, 2).Element)
+	assert.True(h.Undo())
+	assert.Equal(byte(E_EMPTY), Board.Tiles.Get(3, 2).Element)
+	assert.False(h.Undo(), "history is limited to its depth")
+	assert.True(h.Redo())
+	assert.True(h.Redo())
+	assert.False(h.Redo())
+	assert.Equal(byte(E_SOLID), Board.Tiles.Get(4, 2).Element)
+
+	// Stats and board switches are undone along with tiles.
+	h.Begin()
+	AddStat(10, 10, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	BoardClose()
+	World.BoardData = append(World.BoardData, nil)
+	World.Info.CurrentBoard = 1
+	BoardCreate()
+	h.End()
+	assert.Equal(2, len(World.BoardData))
+	assert.True(h.Undo())
+	assert.Equal(1, len(World.BoardData))
+	assert.Equal(int16(0), World.Info.CurrentBoard)
+	assert.Equal(int16(0), Board.Stats.Count)
+	assert.True(h.Redo())
+	assert.Equal(int16(1), World.Info.CurrentBoard)
+	BoardOpen(0)
+	assert.Equal(int16(1), Board.Stats.Count)
+
+	// Taking a snapshot leaves bound stats alone.
+	data := []byte("@bound\r")
+	Board.Stats.At(1).Data = &data
+	Board.Stats.At(1).DataLen = int16(len(data))
+	AddStat(11, 10, E_OBJECT, 0x0F, 3, StatTemplateDefault)
+	Board.Stats.At(2).Data = &data
+	Board.Stats.At(2).DataLen = int16(len(data))
+	EditorSnapshotTake()
+	assert.Equal(int16(len(data)), Board.Stats.At(2).DataLen)
+	BoardOpen(0)
+	assert.Equal(Board.Stats.At(1).Data, Board.Stats.At(2).Data)
+}
@@ -70,7 +70,9 @@ func BoardSerialize(b *TBoard, w io.Writer) error {
 		return err
 	}
 	for ix = 0; ix <= b.Stats.Count; ix++ {
-		stat := b.Stats.At(ix)
+		// Bound stats are written as a reference to the first stat sharing
+		// their data; the board itself is left as it is.
+		stat := *b.Stats.At(ix)
 		if stat.DataLen > 0 {
 			for iy = 1; iy <= ix-1; iy++ {
 				if b.Stats.At(iy).Data == stat.Data {
@@ -78,12 +80,12 @@ func BoardSerialize(b *TBoard, w io.Writer) error {
 				}
 			}
 		}
-		err = WriteStat(w, *b.Stats.At(ix))
+		err = WriteStat(w, stat)
 		if err != nil {
 			return err
 		}
 		if stat.DataLen > 0 {
-			err = WritePBytes(w, *b.Stats.At(ix).Data, int(b.Stats.At(ix).DataLen))
+			err = WritePBytes(w, *stat.Data, int(stat.DataLen))
 			if err != nil {
 				return err
 			}
@@ -914,7 +914,7 @@ func GamePlayLoop(boardChanged bool) {
 	}
 	SoundClearQueue()
 	if GameStateElement == E_PLAYER {
-		if World.Info.Health <= 0 {
+		if World.Info.Health <= 0 && !GameTestPlaying {
 			HighScoresAdd(World.Info.Score)
 		}
 	} else if GameStateElement == E_MONITOR {
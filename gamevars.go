@@ -102,7 +102,9 @@ var (
 	HighScoreList               []format.THighScoreEntry
 	ConfigRegistration          string
 	ConfigWorldFile             string
+	PlayerName                  string
 	EditorEnabled               bool
+	GameTestPlaying             bool
 	GameVersion                 string
 	ParsingConfigFile           bool
 	ResetConfig                 bool
@@ -71,8 +71,11 @@ func HighScoresAdd(score int16) {
 		textWindow.Title = "New high score for " + World.Info.Name
 		textWindow.DrawOpen()
 		textWindow.Draw(false, false)
-		name = ""
-		PopupPromptString("Congratulations!  Enter your name:", &name)
+		if Length(PlayerName) != 0 {
+			name = PlayerName
+		} else {
+			PopupPromptString("Congratulations!  Enter your name:", &name)
+		}
 		HighScoreList[listPos-1].Name = name
 		HighScoresSave()
 		textWindow.DrawClose()
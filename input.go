@@ -1,10 +1,14 @@
 package main // unit: Input
 
 const (
+	KEY_CTRL_C    = '\x03'
 	KEY_BACKSPACE = '\x08'
 	KEY_TAB       = '\t'
 	KEY_ENTER     = '\r'
+	KEY_CTRL_V    = '\x16'
+	KEY_CTRL_X    = '\x18'
 	KEY_CTRL_Y    = '\x19'
+	KEY_CTRL_Z    = '\x1a'
 	KEY_ESCAPE    = '\x1b'
 	KEY_ALT_P     = '\x99'
 	KEY_F1        = '\xbb'
@@ -29,6 +33,19 @@ const (
 	KEY_END       = '\xcf'
 )
 
+const (
+	MOUSE_BUTTON_LEFT   = 1
+	MOUSE_BUTTON_RIGHT  = 2
+	MOUSE_BUTTON_MIDDLE = 4
+)
+
+type TMouseEvent struct {
+	X, Y         int16 // the text cell under the mouse, from 0
+	MoveX, MoveY int16 // relative motion, in pixels
+	Buttons      byte  // the buttons held after the event
+	Wheel        int16 // wheel clicks, away from the user being positive
+}
+
 var (
 	InputDeltaX, InputDeltaY                     int16
 	InputShiftPressed                            bool
@@ -42,6 +59,12 @@ var (
 	InputJoystickMoved                           bool
 	JoystickXInitial, JoystickYInitial           int16
 	InputLastDeltaX, InputLastDeltaY             int16
+	// The mouse as a pointer, whether or not it is used to play.
+	InputMouseCellX, InputMouseCellY int16 // the text cell under the mouse
+	InputMouseMoved                  bool  // to another cell, since the last update
+	InputMouseButtons                byte  // the buttons held
+	InputMouseClicked                byte  // the buttons pressed since the last update
+	InputMouseWheel                  int16 // wheel clicks since the last update
 )
 
 // implementation uses: Dos, Crt, Keys, Sounds
@@ -177,6 +200,23 @@ func InputUpdate() {
 	InputDeltaY = 0
 	InputShiftPressed = false
 	InputJoystickMoved = false
+	InputMouseMoved = false
+	InputMouseClicked = 0
+	InputMouseWheel = 0
+	for _, e := range ReadMouse() {
+		if e.X != InputMouseCellX || e.Y != InputMouseCellY {
+			InputMouseMoved = true
+			InputMouseCellX = e.X
+			InputMouseCellY = e.Y
+		}
+		InputMouseClicked |= e.Buttons &^ InputMouseButtons
+		InputMouseButtons = e.Buttons
+		InputMouseWheel += e.Wheel
+		if InputMouseEnabled {
+			InputMouseX += e.MoveX
+			InputMouseY += e.MoveY
+		}
+	}
 	for KeyPressed() {
 		InputKeyPressed = ReadKey()
 		if InputKeyPressed == '\x00' || InputKeyPressed == '\x01' || InputKeyPressed == '\x02' {
@@ -243,11 +283,6 @@ func InputUpdate() {
 			InputShiftAccepted = false
 		}
 	} else if InputMouseEnabled {
-		// stub
-		/* regs.AX = 0x0B
-		Intr(0x33, regs)
-		InputMouseX += int16(regs.CX)
-		InputMouseY += int16(regs.DX) */
 		if Abs(InputMouseX) > Abs(InputMouseY) {
 			if Abs(InputMouseX) > InputMouseActivationX {
 				if InputMouseX > 0 {
@@ -268,17 +303,14 @@ func InputUpdate() {
 			}
 		}
 
-		// stub
-		/* regs.AX = 0x03
-		Intr(0x33, regs)
-		if regs.BX&1 != 0 {
+		if InputMouseButtons&MOUSE_BUTTON_LEFT != 0 {
 			if !InputShiftAccepted {
 				InputShiftPressed = true
 			}
-		} else */{
+		} else {
 			InputShiftAccepted = false
 		}
-		/* if regs.BX&6 != 0 {
+		if InputMouseButtons&(MOUSE_BUTTON_RIGHT|MOUSE_BUTTON_MIDDLE) != 0 {
 			if InputDeltaX != 0 || InputDeltaY != 0 {
 				InputMouseButtonX = InputDeltaX
 				InputMouseButtonY = InputDeltaY
@@ -286,7 +318,7 @@ func InputUpdate() {
 				InputDeltaX = InputMouseButtonX
 				InputDeltaY = InputMouseButtonY
 			}
-		} else */{
+		} else {
 			InputMouseButtonX = 0
 			InputMouseButtonY = 0
 		}
@@ -299,12 +331,11 @@ func InputUpdate() {
 }
 
 func InputInitMouse() (InputInitMouse bool) {
-	// stub
-	/* var regs Registers
-	regs.AX = 0
-	Intr(0x33, regs)
-	InputInitMouse = regs.AX == 0 */
-	InputInitMouse = false
+	InputInitMouse = PlatformHasMouse()
+	// An input log is played back with a mouse if it was recorded with one.
+	if playbackInput != nil {
+		InputInitMouse = playbackInput.HasMouse
+	}
 	return
 }
 
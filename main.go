@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	platform, err := PlatformNew(PlatformArgument())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, PlatformArgument()+": "+err.Error())
+		fmt.Fprintln(os.Stderr, "Available platforms:", PlatformNames())
+		os.Exit(1)
+	}
+	PlatformSet(platform)
+	if err := platform.Run(ZZTMain); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
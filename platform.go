@@ -1,5 +1,19 @@
 package main
 
+import (
+	"errors"
+	"os"
+	"sort"
+)
+
+// Platform - video, input, timing and audio backends
+//
+// Backends register themselves from init() in files guarded by build tags;
+// any number of them can be compiled into one binary, and the one used is
+// picked at runtime (/B:<name>, or else the one with the highest priority).
+// The engine only ever talks to the current platform through the free
+// functions below.
+
 type IdleMode int
 
 const (
@@ -7,3 +21,199 @@ const (
 	IdleUntilFrame
 	IdleMinimal
 )
+
+type (
+	PlatformVideo interface {
+		SetMode(columns int)
+		ClrScr(backgroundColor uint8)
+		WriteText(x, y int16, color byte, text string)
+		SetCursorVisible(v bool)
+		Move(x, y, width int16, buffer *[]byte, toVideo bool)
+	}
+	PlatformInput interface {
+		UpdateModifiers()
+		KeyPressed() bool
+		ReadKey() byte
+	}
+	PlatformTimer interface {
+		TimerTicks() int
+		Idle(mode IdleMode)
+		Delay(ms uint32)
+	}
+	PlatformAudio interface {
+		Queue(pattern string, clear bool)
+		OnPitTick()
+	}
+	Platform interface {
+		Video() PlatformVideo
+		Input() PlatformInput
+		Timer() PlatformTimer
+		Audio() PlatformAudio
+		// Run sets the platform up, calls main and tears the platform down
+		// once main returns.
+		Run(main func()) error
+	}
+	// PlatformRemote is implemented by platforms whose user is not at the
+	// machine running the game, like the door. Programs the user could
+	// otherwise reach, like a text editor, must not be started on them.
+	PlatformRemote interface {
+		Remote()
+	}
+	// PlatformMouse is implemented by inputs which have a mouse.
+	PlatformMouse interface {
+		// ReadMouse returns the mouse events since the last call.
+		ReadMouse() []TMouseEvent
+	}
+	TPlatformEntry struct {
+		Name     string
+		Priority int
+		New      func() Platform
+	}
+	// NullAudio is a PlatformAudio for platforms without sound.
+	NullAudio struct{}
+)
+
+var ErrPlatformUnknown = errors.New("Unknown platform!")
+
+var (
+	CurrentPlatform  Platform
+	platformVideo    PlatformVideo
+	platformInput    PlatformInput
+	platformTimer    PlatformTimer
+	platformAudio    PlatformAudio
+	platformRegistry []TPlatformEntry
+)
+
+func (a NullAudio) Queue(pattern string, clear bool) {
+	// no-op
+}
+
+func (a NullAudio) OnPitTick() {
+	// no-op
+}
+
+func PlatformRegister(name string, priority int, newPlatform func() Platform) {
+	platformRegistry = append(platformRegistry, TPlatformEntry{name, priority, newPlatform})
+	sort.SliceStable(platformRegistry, func(i, j int) bool {
+		return platformRegistry[i].Priority > platformRegistry[j].Priority
+	})
+}
+
+// PlatformNames lists the compiled-in platforms, the default one first.
+func PlatformNames() []string {
+	names := make([]string, len(platformRegistry))
+	for i, entry := range platformRegistry {
+		names[i] = entry.Name
+	}
+	return names
+}
+
+// PlatformNew creates the named platform, or the default one if name is empty.
+func PlatformNew(name string) (Platform, error) {
+	for _, entry := range platformRegistry {
+		if Length(name) == 0 || entry.Name == name {
+			return entry.New(), nil
+		}
+	}
+	return nil, ErrPlatformUnknown
+}
+
+// PlatformSet makes p the platform used by the engine.
+func PlatformSet(p Platform) {
+	CurrentPlatform = p
+	platformVideo = p.Video()
+	platformInput = p.Input()
+	platformTimer = p.Timer()
+	platformAudio = p.Audio()
+}
+
+// PlatformArgument returns the platform name given with /B:, if any.
+func PlatformArgument() string {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && UpCase(pArg[1]) == 'B' && pArg[2] == ':' {
+			return pArg[3:]
+		}
+	}
+	return ""
+}
+
+// PlatformIsRemote tells if the current platform is a PlatformRemote.
+func PlatformIsRemote() bool {
+	_, remote := CurrentPlatform.(PlatformRemote)
+	return remote
+}
+
+// PlatformHasMouse tells if the current platform's input is a PlatformMouse.
+func PlatformHasMouse() bool {
+	_, mouse := platformInput.(PlatformMouse)
+	return mouse
+}
+
+func TimerTicks() int {
+	return platformTimer.TimerTicks()
+}
+
+func MemAvail() int32 {
+	// stub
+	return 655360
+}
+
+func SetCBreak(v bool) {
+	// stub
+}
+
+func Idle(mode IdleMode) {
+	platformTimer.Idle(mode)
+}
+
+func Delay(ms uint32) {
+	platformTimer.Delay(ms)
+}
+
+func IVideoSetMode(columns int) {
+	platformVideo.SetMode(columns)
+}
+
+func IVideoClrScr(backgroundColor uint8) {
+	platformVideo.ClrScr(backgroundColor)
+}
+
+func IVideoWriteText(x, y int16, color byte, text string) {
+	platformVideo.WriteText(x, y, color, text)
+}
+
+func IVideoSetCursorVisible(v bool) {
+	platformVideo.SetCursorVisible(v)
+}
+
+func IVideoMove(x, y, width int16, buffer *[]byte, toVideo bool) {
+	platformVideo.Move(x, y, width, buffer, toVideo)
+}
+
+func IKeysUpdateModifiers() {
+	platformInput.UpdateModifiers()
+}
+
+func IKeyPressed() bool {
+	return platformInput.KeyPressed()
+}
+
+func IReadKey() byte {
+	return platformInput.ReadKey()
+}
+
+func IReadMouse() []TMouseEvent {
+	if mouse, ok := platformInput.(PlatformMouse); ok {
+		return mouse.ReadMouse()
+	}
+	return nil
+}
+
+func IAudioQueue(pattern string, clear bool) {
+	platformAudio.Queue(pattern, clear)
+}
+
+func IAudioPitTick() {
+	platformAudio.OnPitTick()
+}
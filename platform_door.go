@@ -0,0 +1,420 @@
+//go:build door
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type DoorPlatform struct {
+	NullAudio
+	frameTickCond        *sync.Cond
+	pitTickCond          *sync.Cond
+	videoUpdateRequested atomic.Bool
+	timerTicks           int
+
+	conn       io.ReadWriteCloser
+	writer     *bufio.Writer
+	writerLock sync.Mutex
+	telnet     bool
+	deadline   time.Time
+	ended      atomic.Bool
+	endMessage string
+	encoder    *AnsiEncoder
+
+	textBuffer     TTextBuffer
+	textBufferSent TTextBuffer
+	textBufferLock sync.Mutex
+	textColumns    int
+
+	keyQueueLock sync.Mutex
+	keyQueue     []TDoorKey
+	lastKeyShift bool
+}
+
+var DoorInfo TDoorInfo
+
+func init() {
+	PlatformRegister("door", 50, func() Platform {
+		return NewDoorPlatform()
+	})
+}
+
+func NewDoorPlatform() *DoorPlatform {
+	return &DoorPlatform{
+		frameTickCond: sync.NewCond(&sync.Mutex{}),
+		pitTickCond:   sync.NewCond(&sync.Mutex{}),
+		encoder:       NewAnsiEncoder(AnsiCharCP437),
+		textColumns:   80,
+		keyQueue:      make([]TDoorKey, 0),
+	}
+}
+
+func (d *DoorPlatform) Video() PlatformVideo { return d }
+func (d *DoorPlatform) Input() PlatformInput { return d }
+func (d *DoorPlatform) Timer() PlatformTimer { return d }
+func (d *DoorPlatform) Audio() PlatformAudio { return d }
+func (d *DoorPlatform) Remote()              {}
+
+type doorStdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (d doorStdio) Close() error {
+	return nil
+}
+
+func (d *DoorPlatform) TimerTicks() int {
+	return d.timerTicks
+}
+
+func (d *DoorPlatform) Idle(mode IdleMode) {
+	if d.ended.Load() {
+		d.endSession()
+	}
+	switch mode {
+	case IdleUntilFrame:
+		d.frameTickCond.L.Lock()
+		d.frameTickCond.Wait()
+		d.frameTickCond.L.Unlock()
+	case IdleUntilPit:
+		d.pitTickCond.L.Lock()
+		d.pitTickCond.Wait()
+		d.pitTickCond.L.Unlock()
+	}
+}
+
+func (d *DoorPlatform) Delay(ms uint32) {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+}
+
+func (d *DoorPlatform) SetMode(columns int) {
+	d.textBufferLock.Lock()
+	defer d.textBufferLock.Unlock()
+	d.textColumns = columns
+}
+
+func (d *DoorPlatform) ClrScr(backgroundColor uint8) {
+	d.textBufferLock.Lock()
+	defer d.textBufferLock.Unlock()
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < d.textColumns; ix++ {
+			d.textBuffer[iy][ix*2] = ' '
+			d.textBuffer[iy][ix*2+1] = backgroundColor << 4
+		}
+	}
+	d.videoUpdateRequested.Store(true)
+}
+
+func (d *DoorPlatform) WriteText(x, y int16, color byte, text string) {
+	if y < 0 || y >= 25 {
+		return
+	}
+	d.textBufferLock.Lock()
+	defer d.textBufferLock.Unlock()
+	for i := 0; i < len(text); i++ {
+		d.textBuffer[y][x*2] = text[i]
+		d.textBuffer[y][x*2+1] = color
+		x++
+		if x >= int16(d.textColumns) {
+			x = 0
+			y++
+			if y >= 25 {
+				break
+			}
+		}
+	}
+	d.videoUpdateRequested.Store(true)
+}
+
+func (d *DoorPlatform) SetCursorVisible(v bool) {
+	// The remote cursor is hidden for the whole session.
+}
+
+func (d *DoorPlatform) Move(x, y, width int16, buffer *[]byte, toVideo bool) {
+	d.textBufferLock.Lock()
+	defer d.textBufferLock.Unlock()
+	if toVideo {
+		if buffer != nil {
+			if width > int16(len(*buffer)>>1) {
+				width = int16(len(*buffer) >> 1)
+			}
+			copy(d.textBuffer[y][int(x)*2:(int(x)+int(width))*2], *buffer)
+		}
+		d.videoUpdateRequested.Store(true)
+	} else {
+		*buffer = make([]byte, width*2)
+		copy(*buffer, d.textBuffer[y][x*2:(x+width)*2])
+	}
+}
+
+func (d *DoorPlatform) UpdateModifiers() {
+	d.keyQueueLock.Lock()
+	defer d.keyQueueLock.Unlock()
+
+	KeysShiftHeld = d.lastKeyShift
+	KeysLeftShiftHeld = d.lastKeyShift
+	KeysRightShiftHeld = false
+	KeysCtrlHeld = false
+	KeysAltHeld = false
+}
+
+func (d *DoorPlatform) KeyPressed() bool {
+	d.keyQueueLock.Lock()
+	defer d.keyQueueLock.Unlock()
+
+	return len(d.keyQueue) > 0
+}
+
+func (d *DoorPlatform) ReadKey() byte {
+	d.keyQueueLock.Lock()
+	defer d.keyQueueLock.Unlock()
+
+	if len(d.keyQueue) <= 0 {
+		return 0
+	} else {
+		v := d.keyQueue[0]
+		d.keyQueue = d.keyQueue[1:]
+		d.lastKeyShift = v.Shift
+		return v.Key
+	}
+}
+
+func (d *DoorPlatform) write(s string) error {
+	d.writerLock.Lock()
+	defer d.writerLock.Unlock()
+
+	if _, err := d.writer.WriteString(s); err != nil {
+		return err
+	}
+	return d.writer.Flush()
+}
+
+func (d *DoorPlatform) flushVideo(force bool) {
+	var sb strings.Builder
+	if !d.videoUpdateRequested.Swap(false) && !force {
+		return
+	}
+	d.textBufferLock.Lock()
+	if force {
+		d.encoder.WriteFull(&sb, &d.textBufferSent, &d.textBuffer, d.textColumns)
+	} else {
+		d.encoder.WriteDiff(&sb, &d.textBufferSent, &d.textBuffer, d.textColumns)
+	}
+	d.textBufferLock.Unlock()
+	if sb.Len() > 0 {
+		if err := d.write(sb.String()); err != nil {
+			d.hangUp("")
+		}
+	}
+}
+
+func (d *DoorPlatform) hangUp(message string) {
+	if d.ended.CompareAndSwap(false, true) {
+		d.endMessage = message
+	}
+}
+
+// endSession is called from the game thread once the caller's time has run
+// out or the connection was lost. A game in progress is saved to the
+// caller's save file, and spectators are sent away, before the door exits.
+func (d *DoorPlatform) endSession() {
+	if GameStateElement == E_PLAYER && World.Info.Health > 0 {
+		WorldSave(SavedGameFileName, ".SAV")
+	}
+	d.flushVideo(false)
+	if Length(d.endMessage) != 0 {
+		d.write("\x1b[0m\x1b[25;1H\r\n" + d.endMessage + "\r\n")
+	}
+	RecordingStop()
+	d.close()
+	// Run does not get to close the spectators, as the door exits here.
+	if Spectators != nil {
+		Spectators.Close()
+	}
+	os.Exit(0)
+}
+
+func (d *DoorPlatform) close() {
+	d.write("\x1b[0m\x1b[?25h\x1b[25;1H\r\n")
+	d.conn.Close()
+}
+
+// A lone ESC is taken to be the Escape key if nothing follows it within
+// this long; terminals send the rest of a sequence right after it.
+const doorEscapeTimeout = 100 * time.Millisecond
+
+func (d *DoorPlatform) readInput() {
+	var parser DoorInputParser
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 256)
+		for {
+			n, err := d.conn.Read(buf)
+			if n > 0 {
+				chunks <- append([]byte(nil), buf[:n]...)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		var keys []TDoorKey
+		var timeout <-chan time.Time
+		if parser.EscapePending() {
+			timeout = time.After(doorEscapeTimeout)
+		}
+		select {
+		case data, ok := <-chunks:
+			if !ok {
+				d.hangUp("")
+				return
+			}
+			keys = parser.Feed(data)
+		case <-timeout:
+			keys = parser.Flush()
+		}
+		d.keyQueueLock.Lock()
+		d.keyQueue = append(d.keyQueue, keys...)
+		d.keyQueueLock.Unlock()
+	}
+}
+
+func doorParseArguments() (dropfile, listenAddr string) {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && pArg[2] == ':' {
+			switch UpCase(pArg[1]) {
+			case 'D':
+				dropfile = pArg[3:]
+			case 'L':
+				listenAddr = pArg[3:]
+			}
+		}
+	}
+	return
+}
+
+func (d *DoorPlatform) openConnection(listenAddr string) error {
+	if Length(listenAddr) != 0 {
+		ln, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Waiting for a telnet connection on " + ln.Addr().String() + "...")
+		conn, err := ln.Accept()
+		ln.Close()
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+		d.telnet = true
+	} else if DoorInfo.CommType == DOOR_COMM_TELNET {
+		conn, err := net.FileConn(os.NewFile(uintptr(DoorInfo.CommHandle), "door"))
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+		d.telnet = true
+	} else {
+		d.conn = doorStdio{os.Stdin, os.Stdout}
+		d.telnet = false
+	}
+
+	if d.telnet {
+		d.writer = bufio.NewWriter(doorTelnetWriter{d.conn})
+		d.conn.Write([]byte{
+			TELNET_IAC, TELNET_WILL, TELNET_OPT_ECHO,
+			TELNET_IAC, TELNET_WILL, TELNET_OPT_SGA,
+			TELNET_IAC, TELNET_DO, TELNET_OPT_SGA,
+			TELNET_IAC, TELNET_DONT, TELNET_OPT_LINEMODE,
+		})
+	} else {
+		d.writer = bufio.NewWriter(d.conn)
+	}
+	return d.write("\x1b[?25l")
+}
+
+func (d *DoorPlatform) Run(main func()) error {
+	dropfile, listenAddr := doorParseArguments()
+	if Length(dropfile) != 0 {
+		info, err := DoorReadDropfile(dropfile)
+		if err != nil {
+			return errors.New(dropfile + ": " + err.Error())
+		}
+		DoorInfo = info
+	} else {
+		DoorInfo.CommType = DOOR_COMM_LOCAL
+		DoorInfo.UserName = "Local"
+	}
+	PlayerName = DoorInfo.DisplayName()
+
+	if err := d.openConnection(listenAddr); err != nil {
+		return err
+	}
+	if addr := SpectatorListenAddress(); Length(addr) != 0 {
+		var err error
+		Spectators, err = SpectatorStart(addr)
+		if err != nil {
+			return err
+		}
+		defer Spectators.Close()
+		SoundQueueListener = Spectators.PublishSound
+	}
+	if DoorInfo.TimeLeft > 0 {
+		d.deadline = time.Now().Add(DoorInfo.TimeLeft)
+	}
+	d.flushVideo(true)
+
+	go d.readInput()
+
+	frameTicker := time.NewTicker(16666667 * time.Nanosecond)
+	pitTicker := time.NewTicker(55 * time.Millisecond)
+	tickerDone := make(chan bool)
+
+	go func() {
+		for {
+			select {
+			case <-frameTicker.C:
+				d.frameTickCond.Broadcast()
+			case <-pitTicker.C:
+				SoundTimerHandler()
+				d.timerTicks++
+				if !d.deadline.IsZero() && time.Now().After(d.deadline) {
+					d.hangUp("Your time is up!  Come back soon.")
+				}
+				d.flushVideo(false)
+				if Spectators != nil {
+					d.textBufferLock.Lock()
+					Spectators.PublishScreen(&d.textBuffer, d.textColumns)
+					d.textBufferLock.Unlock()
+				}
+				d.pitTickCond.Broadcast()
+			case <-tickerDone:
+				return
+			}
+		}
+	}()
+
+	main()
+
+	frameTicker.Stop()
+	pitTicker.Stop()
+	tickerDone <- true
+
+	d.flushVideo(false)
+	d.close()
+	return nil
+}
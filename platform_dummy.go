@@ -1,66 +1,137 @@
-//go:build dummy
-
 package main
 
-var timerTicks int = 0
+import (
+	"os"
+	"time"
+)
 
-func TimerTicks() int {
-	// no-op
-	timerTicks += 1
-	return timerTicks
+// The dummy platform has no display and no keyboard, and runs on a virtual
+// clock which only moves forward when the game idles. Together with input
+// log playback, this lets sessions be replayed (and recorded) faster than
+// real time. It is always compiled in.
+
+const dummyPitInterval = 55 * time.Millisecond
+
+type DummyPlatform struct {
+	NullAudio
+	time        time.Duration
+	textBuffer  TTextBuffer
+	textColumns int
 }
 
-func MemAvail() int32 {
-	// no-op
-	return 655360
+func init() {
+	PlatformRegister("dummy", 0, func() Platform {
+		return NewDummyPlatform()
+	})
 }
 
-func SetCBreak(v bool) {
-	// no-op
+func NewDummyPlatform() *DummyPlatform {
+	return &DummyPlatform{textColumns: 80}
 }
 
-func Idle(mode IdleMode) {
-	// no-op
+func (d *DummyPlatform) Video() PlatformVideo { return d }
+func (d *DummyPlatform) Input() PlatformInput { return d }
+func (d *DummyPlatform) Timer() PlatformTimer { return d }
+func (d *DummyPlatform) Audio() PlatformAudio { return d }
+
+func (d *DummyPlatform) Run(main func()) error {
+	RecordingClock = func() time.Duration {
+		return d.time
+	}
+	main()
+	return nil
 }
 
-func Delay(ms uint32) {
-	// no-op
+func (d *DummyPlatform) advance(delta time.Duration) {
+	pitTicks := d.TimerTicks()
+	d.time += delta
+	for ; pitTicks < d.TimerTicks(); pitTicks++ {
+		SoundTimerHandler()
+	}
+	if InputPlaybackFinished() {
+		RecordingStop()
+		os.Exit(0)
+	}
 }
 
-func IVideoSetMode(columns int) {
-	// no-op
+func (d *DummyPlatform) TimerTicks() int {
+	return int(d.time / dummyPitInterval)
 }
 
-func IVideoClrScr(backgroundColor uint8) {
-	// no-op
+func (d *DummyPlatform) Idle(mode IdleMode) {
+	switch mode {
+	case IdleUntilFrame:
+		d.advance(16666667 * time.Nanosecond)
+	case IdleUntilPit:
+		d.advance(dummyPitInterval - d.time%dummyPitInterval)
+	default:
+		d.advance(time.Millisecond)
+	}
 }
 
-func IVideoWriteText(x, y int16, color byte, text string) {
-	// no-op
+func (d *DummyPlatform) Delay(ms uint32) {
+	d.advance(time.Duration(ms) * time.Millisecond)
 }
 
-func IVideoSetCursorVisible(v bool) {
-	// no-op
+func (d *DummyPlatform) SetMode(columns int) {
+	d.textColumns = columns
+}
+
+func (d *DummyPlatform) ClrScr(backgroundColor uint8) {
+	for iy := 0; iy < 25; iy++ {
+		for ix := 0; ix < d.textColumns; ix++ {
+			d.textBuffer[iy][ix*2] = ' '
+			d.textBuffer[iy][ix*2+1] = backgroundColor << 4
+		}
+	}
+}
+
+func (d *DummyPlatform) WriteText(x, y int16, color byte, text string) {
+	if y < 0 || y >= 25 {
+		return
+	}
+	for i := 0; i < len(text); i++ {
+		d.textBuffer[y][x*2] = text[i]
+		d.textBuffer[y][x*2+1] = color
+		x++
+		if x >= int16(d.textColumns) {
+			x = 0
+			y++
+			if y >= 25 {
+				break
+			}
+		}
+	}
 }
 
-func VideoMove(x, y, width int16, buffer *[]byte, toVideo bool) {
+func (d *DummyPlatform) SetCursorVisible(v bool) {
 	// no-op
 }
 
-func KeysUpdateModifiers() {
+func (d *DummyPlatform) Move(x, y, width int16, buffer *[]byte, toVideo bool) {
+	if toVideo {
+		if buffer != nil {
+			if width > int16(len(*buffer)>>1) {
+				width = int16(len(*buffer) >> 1)
+			}
+			copy(d.textBuffer[y][int(x)*2:(int(x)+int(width))*2], *buffer)
+		}
+	} else {
+		*buffer = make([]byte, width*2)
+		copy(*buffer, d.textBuffer[y][x*2:(x+width)*2])
+	}
+}
+
+func (d *DummyPlatform) UpdateModifiers() {
 	// no-op
 }
 
-func KeyPressed() bool {
+func (d *DummyPlatform) KeyPressed() bool {
 	// no-op
 	return false
 }
 
-func ReadKey() byte {
+func (d *DummyPlatform) ReadKey() byte {
 	// no-op
 	return 0
 }
-
-func main() {
-	ZZTMain()
-}
@@ -3,8 +3,6 @@
 package main
 
 import (
-	"sync"
-
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -24,24 +22,35 @@ var pcScancodeMap = []byte{
 	0x4D, 0x4B, 0x50, 0x48, 0x45,
 }
 
-var KeyQueueLock = sync.Mutex{}
-var KeyQueue = make([]byte, 0)
-
-func KeysUpdateModifiers() {
+func (p *SdlPlatform) UpdateModifiers() {
 	// stub
 }
 
-func ParseSDLKeyboardEvent(e *sdl.KeyboardEvent) {
+func (p *SdlPlatform) parseKeyboardEvent(e *sdl.KeyboardEvent) {
 	KeysLeftShiftHeld = (e.Keysym.Mod & sdl.KMOD_LSHIFT) != 0
 	KeysRightShiftHeld = (e.Keysym.Mod & sdl.KMOD_RSHIFT) != 0
 	KeysShiftHeld = (e.Keysym.Mod & sdl.KMOD_SHIFT) != 0
 	KeysCtrlHeld = (e.Keysym.Mod & sdl.KMOD_CTRL) != 0
 	KeysAltHeld = (e.Keysym.Mod & sdl.KMOD_ALT) != 0
 
+	if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F12 {
+		// F12 starts/stops a GIF capture, Shift+F12 an APNG one.
+		if KeysShiftHeld {
+			p.toggleCapture(CAPTURE_APNG)
+		} else {
+			p.toggleCapture(CAPTURE_GIF)
+		}
+		return
+	}
+
 	if e.Type == sdl.KEYDOWN {
 		k := byte(0)
 		if KeysAltHeld && e.Keysym.Sym == 'p' {
 			k = KEY_ALT_P
+		} else if KeysCtrlHeld && !KeysAltHeld && e.Keysym.Sym >= 'c' && e.Keysym.Sym <= 'z' {
+			// Ctrl+letter gives its control code, as on DOS. Ctrl-A and
+			// Ctrl-B are left out, as 0x01 and 0x02 prefix extended keys.
+			k = byte(e.Keysym.Sym - 'a' + 1)
 		} else if e.Keysym.Sym > 0 && e.Keysym.Sym < 127 {
 			k = byte(e.Keysym.Sym)
 		} else if e.Keysym.Scancode <= 83 {
@@ -49,39 +58,109 @@ func ParseSDLKeyboardEvent(e *sdl.KeyboardEvent) {
 		}
 
 		if ((k & 0x7F) != 0) && !(k >= 32 && k < 127) {
-			KeyQueueLock.Lock()
-			defer KeyQueueLock.Unlock()
+			p.keyQueueLock.Lock()
+			defer p.keyQueueLock.Unlock()
 
-			// KeyQueue = append(KeyQueue, k)
-			KeyQueue = []byte{k}
+			// p.keyQueue = append(p.keyQueue, k)
+			p.keyQueue = []byte{k}
 		}
 	}
 }
 
-func ParseSDLTextInputEvent(e *sdl.TextInputEvent) {
+func (p *SdlPlatform) parseTextInputEvent(e *sdl.TextInputEvent) {
 	if e.Type == sdl.TEXTINPUT {
+		if KeysCtrlHeld && !KeysAltHeld {
+			// already sent as a control code
+			return
+		}
 		if e.Text[0] >= 32 && e.Text[0] < 127 {
-			KeyQueue = []byte{e.Text[0]}
+			p.keyQueue = []byte{e.Text[0]}
 		}
 	}
 }
 
-func KeyPressed() bool {
-	KeyQueueLock.Lock()
-	defer KeyQueueLock.Unlock()
+// mouseCell returns the text cell at a point of the window.
+func (p *SdlPlatform) mouseCell(x, y int32) (int16, int16) {
+	w, h := p.window.GetSize()
+	if w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	cx := int16(x * 640 / w / 8)
+	cy := int16(y * 350 / h / 14)
+	return Max(0, Min(cx, int16(p.textColumns)-1)), Max(0, Min(cy, 24))
+}
+
+func (p *SdlPlatform) queueMouseEvent() {
+	p.keyQueueLock.Lock()
+	defer p.keyQueueLock.Unlock()
+
+	p.mouseQueue = append(p.mouseQueue, p.mouseState)
+	p.mouseState.MoveX = 0
+	p.mouseState.MoveY = 0
+	p.mouseState.Wheel = 0
+}
+
+func (p *SdlPlatform) parseMouseMotionEvent(e *sdl.MouseMotionEvent) {
+	p.mouseState.X, p.mouseState.Y = p.mouseCell(e.X, e.Y)
+	p.mouseState.MoveX = int16(e.XRel)
+	p.mouseState.MoveY = int16(e.YRel)
+	p.queueMouseEvent()
+}
+
+func (p *SdlPlatform) parseMouseButtonEvent(e *sdl.MouseButtonEvent) {
+	var button byte
+	switch e.Button {
+	case sdl.BUTTON_LEFT:
+		button = MOUSE_BUTTON_LEFT
+	case sdl.BUTTON_RIGHT:
+		button = MOUSE_BUTTON_RIGHT
+	case sdl.BUTTON_MIDDLE:
+		button = MOUSE_BUTTON_MIDDLE
+	default:
+		return
+	}
+	p.mouseState.X, p.mouseState.Y = p.mouseCell(e.X, e.Y)
+	if e.Type == sdl.MOUSEBUTTONDOWN {
+		p.mouseState.Buttons |= button
+	} else {
+		p.mouseState.Buttons &^= button
+	}
+	p.queueMouseEvent()
+}
+
+func (p *SdlPlatform) parseMouseWheelEvent(e *sdl.MouseWheelEvent) {
+	p.mouseState.Wheel = int16(e.Y)
+	if e.Direction == sdl.MOUSEWHEEL_FLIPPED {
+		p.mouseState.Wheel = -p.mouseState.Wheel
+	}
+	p.queueMouseEvent()
+}
+
+func (p *SdlPlatform) ReadMouse() []TMouseEvent {
+	p.keyQueueLock.Lock()
+	defer p.keyQueueLock.Unlock()
+
+	events := p.mouseQueue
+	p.mouseQueue = nil
+	return events
+}
+
+func (p *SdlPlatform) KeyPressed() bool {
+	p.keyQueueLock.Lock()
+	defer p.keyQueueLock.Unlock()
 
-	return len(KeyQueue) > 0
+	return len(p.keyQueue) > 0
 }
 
-func ReadKey() byte {
-	KeyQueueLock.Lock()
-	defer KeyQueueLock.Unlock()
+func (p *SdlPlatform) ReadKey() byte {
+	p.keyQueueLock.Lock()
+	defer p.keyQueueLock.Unlock()
 
-	if len(KeyQueue) <= 0 {
+	if len(p.keyQueue) <= 0 {
 		return 0
 	} else {
-		v := KeyQueue[0]
-		KeyQueue = KeyQueue[1:]
+		v := p.keyQueue[0]
+		p.keyQueue = p.keyQueue[1:]
 		return v
 	}
 }
@@ -17,66 +17,100 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-var mainTaskQueue = make(chan func())
-var FrameTickCond = sync.NewCond(&sync.Mutex{})
-var PitTickCond = sync.NewCond(&sync.Mutex{})
-var VideoWindow *sdl.Window
-var VideoRenderer *sdl.Renderer
-var VideoZTexture *sdl.Texture
-var VideoUpdateRequested atomic.Bool
-var timerTicks int
-
-func MainThreadAsync(f func()) {
-	mainTaskQueue <- f
+type SdlPlatform struct {
+	mainTaskQueue        chan func()
+	frameTickCond        *sync.Cond
+	pitTickCond          *sync.Cond
+	window               *sdl.Window
+	renderer             *sdl.Renderer
+	zTexture             *sdl.Texture
+	videoUpdateRequested atomic.Bool
+	timerTicks           int
+
+	textBuffer   TTextBuffer
+	textColumns  int
+	blinkState   bool
+	capture      *Capture
+	captureStart time.Time
+
+	keyQueueLock sync.Mutex
+	keyQueue     []byte
+	mouseQueue   []TMouseEvent
+	mouseState   TMouseEvent
 }
 
-func MainThreadSync(f func()) {
-	done := make(chan bool, 1)
-	mainTaskQueue <- func() {
-		f()
-		done <- true
+func init() {
+	PlatformRegister("sdl2", 100, func() Platform {
+		return NewSdlPlatform()
+	})
+}
+
+func NewSdlPlatform() *SdlPlatform {
+	CurrentAudioSimulator = NewAudioSimulatorNearest(48000, byte(32))
+	return &SdlPlatform{
+		mainTaskQueue: make(chan func()),
+		frameTickCond: sync.NewCond(&sync.Mutex{}),
+		pitTickCond:   sync.NewCond(&sync.Mutex{}),
+		textColumns:   80,
+		keyQueue:      make([]byte, 0),
 	}
-	<-done
 }
 
-func TimerTicks() int {
-	return timerTicks
+func (p *SdlPlatform) Video() PlatformVideo { return p }
+func (p *SdlPlatform) Input() PlatformInput { return p }
+func (p *SdlPlatform) Timer() PlatformTimer { return p }
+
+// The audio callback is called from C, so the simulator is a global.
+func (p *SdlPlatform) Audio() PlatformAudio { return CurrentAudioSimulator }
+
+func (p *SdlPlatform) MainThreadAsync(f func()) {
+	p.mainTaskQueue <- f
 }
 
-func MemAvail() int32 {
-	// stub
-	return 655360
+func (p *SdlPlatform) MainThreadSync(f func()) {
+	done := make(chan bool, 1)
+	p.mainTaskQueue <- func() {
+		f()
+		done <- true
+	}
+	<-done
 }
 
-func SetCBreak(v bool) {
-	// stub
+func (p *SdlPlatform) TimerTicks() int {
+	return p.timerTicks
 }
 
-func Idle(mode IdleMode) {
+func (p *SdlPlatform) Idle(mode IdleMode) {
 	switch mode {
 	case IdleUntilFrame:
-		FrameTickCond.L.Lock()
-		FrameTickCond.Wait()
-		FrameTickCond.L.Unlock()
+		p.frameTickCond.L.Lock()
+		p.frameTickCond.Wait()
+		p.frameTickCond.L.Unlock()
 	case IdleUntilPit:
-		PitTickCond.L.Lock()
-		PitTickCond.Wait()
-		PitTickCond.L.Unlock()
+		p.pitTickCond.L.Lock()
+		p.pitTickCond.Wait()
+		p.pitTickCond.L.Unlock()
 	}
 }
 
-func Delay(ms uint32) {
+func (p *SdlPlatform) Delay(ms uint32) {
 	// sdl.Delay(ms)
 	time.Sleep(time.Duration(ms) * time.Millisecond)
 }
 
-func updateSdlEvents() {
+func (p *SdlPlatform) updateEvents() {
 	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 		switch e := event.(type) {
 		case *sdl.KeyboardEvent:
-			ParseSDLKeyboardEvent(e)
+			p.parseKeyboardEvent(e)
 		case *sdl.TextInputEvent:
-			ParseSDLTextInputEvent(e)
+			p.parseTextInputEvent(e)
+		case *sdl.MouseMotionEvent:
+			p.parseMouseMotionEvent(e)
+		case *sdl.MouseButtonEvent:
+			p.parseMouseButtonEvent(e)
+		case *sdl.MouseWheelEvent:
+			p.parseMouseWheelEvent(e)
 		}
 	}
 }
@@ -90,36 +124,35 @@ func ZooSdlAudioCallback(userdata unsafe.Pointer, stream *C.Uint8, length C.int)
 	CurrentAudioSimulator.Simulate(buf)
 }
 
-func main() {
-	var err error
+func (p *SdlPlatform) Run(main func()) (err error) {
 	runtime.LockOSThread()
 	if runtime.NumCPU() > 2 {
 		runtime.GOMAXPROCS(2)
 	}
 
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		panic(err)
+		return err
 	}
 	defer sdl.Quit()
 
-	VideoWindow, err = sdl.CreateWindow("OpenZoo/Go", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+	p.window, err = sdl.CreateWindow("OpenZoo/Go", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
 		640, 350, sdl.WINDOW_SHOWN)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer VideoWindow.Destroy()
+	defer p.window.Destroy()
 
-	VideoRenderer, err = sdl.CreateRenderer(VideoWindow, -1, 0)
+	p.renderer, err = sdl.CreateRenderer(p.window, -1, 0)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer VideoRenderer.Destroy()
+	defer p.renderer.Destroy()
 
-	VideoZTexture, err = VideoRenderer.CreateTexture(uint32(sdl.PIXELFORMAT_ABGR32), sdl.TEXTUREACCESS_STREAMING, 640, 350)
+	p.zTexture, err = p.renderer.CreateTexture(uint32(sdl.PIXELFORMAT_ABGR32), sdl.TEXTUREACCESS_STREAMING, 640, 350)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer VideoZTexture.Destroy()
+	defer p.zTexture.Destroy()
 
 	/* file, _ := os.Create("./cpu.pprof")
 	pprof.StartCPUProfile(file)
@@ -130,7 +163,6 @@ func main() {
 	blinkTicker := time.NewTicker(266666667 * time.Nanosecond)
 	tickerDone := make(chan bool)
 
-	CurrentAudioSimulator = NewAudioSimulatorNearest(48000, byte(32))
 	audioSpec := sdl.AudioSpec{
 		Freq:     48000,
 		Format:   sdl.AUDIO_U8,
@@ -140,7 +172,7 @@ func main() {
 	}
 	err = sdl.OpenAudio(&audioSpec, nil)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer sdl.CloseAudio()
 	sdl.PauseAudio(false)
@@ -148,40 +180,70 @@ func main() {
 	sdl.StartTextInput()
 	defer sdl.StopTextInput()
 
+	if addr := SpectatorListenAddress(); Length(addr) != 0 {
+		Spectators, err = SpectatorStart(addr)
+		if err != nil {
+			return err
+		}
+		defer Spectators.Close()
+		SoundQueueListener = Spectators.PublishSound
+	}
+	spectatorFrame := 0
+
 	go func() {
 		for {
 			select {
 			case <-blinkTicker.C:
-				MainThreadAsync(ZooSdlToggleBlinkChars)
+				p.MainThreadAsync(p.toggleBlinkChars)
 			case <-frameTicker.C:
-				MainThreadAsync(updateSdlEvents)
-				MainThreadAsync(func() {
-					VideoRenderer.Copy(VideoZTexture, nil, nil)
-					VideoRenderer.Present()
+				p.MainThreadAsync(p.updateEvents)
+				p.MainThreadAsync(func() {
+					p.renderer.Copy(p.zTexture, nil, nil)
+					p.renderer.Present()
+					if p.capture != nil {
+						p.captureFrame()
+					}
 				})
-				FrameTickCond.Broadcast()
+				if Spectators != nil {
+					// Spectators are sent 15 frames per second.
+					spectatorFrame++
+					if spectatorFrame >= 4 {
+						spectatorFrame = 0
+						p.MainThreadAsync(func() {
+							Spectators.PublishScreen(&p.textBuffer, p.textColumns)
+						})
+					}
+				}
+				p.frameTickCond.Broadcast()
 			case <-pitTicker.C:
 				SoundTimerHandler()
-				timerTicks++
-				PitTickCond.Broadcast()
+				p.timerTicks++
+				p.pitTickCond.Broadcast()
 			case <-tickerDone:
 				return
 			}
 		}
 	}()
 
+	defer func() {
+		if p.capture != nil {
+			p.capture.Stop()
+		}
+	}()
+
 	go func() {
-		ZZTMain()
+		main()
 
 		frameTicker.Stop()
 		pitTicker.Stop()
 		blinkTicker.Stop()
 		tickerDone <- true
 
-		close(mainTaskQueue)
+		close(p.mainTaskQueue)
 	}()
 
-	for f := range mainTaskQueue {
+	for f := range p.mainTaskQueue {
 		f()
 	}
+	return nil
 }
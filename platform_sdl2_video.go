@@ -7,17 +7,12 @@ import (
 	_ "embed"
 )
 import (
+	"time"
 	"unsafe"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-//go:embed ascii.chr
-var charsetData []byte
-var textBuffer [25][160]byte
-var textColumns int = 80
-var blinkState bool = false
-
 var palette = []uint32{
 	0x000000FF,
 	0x0000AAFF,
@@ -37,21 +32,21 @@ var palette = []uint32{
 	0xFFFFFFFF,
 }
 
-func redrawChar(ix, iy int) {
+func (p *SdlPlatform) redrawChar(ix, iy int) {
 	// TODO: This is *super* slow.
 
 	px := ix * 8
 	py := iy * 14
-	data, pitch, err := VideoZTexture.Lock(&sdl.Rect{X: int32(px), Y: int32(py), W: 8, H: 14})
+	data, pitch, err := p.zTexture.Lock(&sdl.Rect{X: int32(px), Y: int32(py), W: 8, H: 14})
 	if err != nil {
 		return
 	}
 
-	ch := int(textBuffer[iy][ix*2]) * 14
-	co := textBuffer[iy][ix*2+1]
+	ch := int(p.textBuffer[iy][ix*2]) * 14
+	co := p.textBuffer[iy][ix*2+1]
 	if co >= 0x80 {
 		co &= 0x7F
-		if blinkState {
+		if p.blinkState {
 			co = (co >> 4) * 0x11
 		}
 	}
@@ -70,50 +65,84 @@ func redrawChar(ix, iy int) {
 		}
 	}
 
-	VideoZTexture.Unlock()
+	p.zTexture.Unlock()
+}
+
+func (p *SdlPlatform) toggleCapture(format int) {
+	if p.capture != nil {
+		capture := p.capture
+		p.capture = nil
+		p.window.SetTitle("OpenZoo/Go")
+		go func() {
+			if err := capture.Stop(); err != nil {
+				p.MainThreadAsync(func() {
+					p.window.SetTitle("OpenZoo/Go - " + capture.Filename + ": " + err.Error())
+				})
+			}
+		}()
+	} else {
+		filename := CaptureNextFilename(format)
+		capture, err := CaptureStart(filename, format)
+		if err != nil {
+			p.window.SetTitle("OpenZoo/Go - " + filename + ": " + err.Error())
+			return
+		}
+		p.capture = capture
+		p.captureStart = time.Now()
+		p.window.SetTitle("OpenZoo/Go - Capturing to " + filename)
+	}
+}
+
+func (p *SdlPlatform) captureFrame() {
+	p.capture.Push(&TCaptureFrame{
+		Screen:  p.textBuffer,
+		Columns: p.textColumns,
+		Blink:   p.blinkState,
+		Time:    time.Since(p.captureStart),
+	})
 }
 
-func ZooSdlToggleBlinkChars() {
-	blinkState = !blinkState
+func (p *SdlPlatform) toggleBlinkChars() {
+	p.blinkState = !p.blinkState
 	for iy := 0; iy < 25; iy++ {
-		for ix := 0; ix < textColumns; ix++ {
-			if textBuffer[iy][ix*2+1] >= 0x81 {
-				redrawChar(ix, iy)
+		for ix := 0; ix < p.textColumns; ix++ {
+			if p.textBuffer[iy][ix*2+1] >= 0x81 {
+				p.redrawChar(ix, iy)
 			}
 		}
 	}
 }
 
-func IVideoSetMode(columns int) {
-	MainThreadSync(func() {
-		textColumns = columns
+func (p *SdlPlatform) SetMode(columns int) {
+	p.MainThreadSync(func() {
+		p.textColumns = columns
 	})
 }
 
-func IVideoClrScr(backgroundColor uint8) {
-	MainThreadAsync(func() {
+func (p *SdlPlatform) ClrScr(backgroundColor uint8) {
+	p.MainThreadAsync(func() {
 		for iy := 0; iy < 25; iy++ {
-			for ix := 0; ix < textColumns; ix++ {
-				textBuffer[iy][ix*2] = ' '
-				textBuffer[iy][ix*2+1] = backgroundColor << 4
-				redrawChar(ix, iy)
+			for ix := 0; ix < p.textColumns; ix++ {
+				p.textBuffer[iy][ix*2] = ' '
+				p.textBuffer[iy][ix*2+1] = backgroundColor << 4
+				p.redrawChar(ix, iy)
 			}
 		}
-		VideoUpdateRequested.Store(true)
+		p.videoUpdateRequested.Store(true)
 	})
 }
 
-func IVideoWriteText(x, y int16, color byte, text string) {
+func (p *SdlPlatform) WriteText(x, y int16, color byte, text string) {
 	if y < 0 || y >= 25 {
 		return
 	}
-	MainThreadAsync(func() {
+	p.MainThreadAsync(func() {
 		for i := 0; i < len(text); i++ {
-			textBuffer[y][x*2] = text[i]
-			textBuffer[y][x*2+1] = color
-			redrawChar(int(x), int(y))
+			p.textBuffer[y][x*2] = text[i]
+			p.textBuffer[y][x*2+1] = color
+			p.redrawChar(int(x), int(y))
 			x++
-			if x >= int16(textColumns) {
+			if x >= int16(p.textColumns) {
 				x = 0
 				y++
 				if y >= 25 {
@@ -121,39 +150,39 @@ func IVideoWriteText(x, y int16, color byte, text string) {
 				}
 			}
 		}
-		VideoUpdateRequested.Store(true)
+		p.videoUpdateRequested.Store(true)
 	})
 }
 
-func IVideoSetCursorVisible(v bool) {
-	MainThreadSync(func() {
+func (p *SdlPlatform) SetCursorVisible(v bool) {
+	p.MainThreadSync(func() {
 		// stub
 	})
 }
 
-func VideoMove(x, y, width int16, buffer *[]byte, toVideo bool) {
+func (p *SdlPlatform) Move(x, y, width int16, buffer *[]byte, toVideo bool) {
 	if toVideo {
-		MainThreadAsync(func() {
+		p.MainThreadAsync(func() {
 			if buffer != nil {
 				if width > int16(len(*buffer)>>1) {
 					width = int16(len(*buffer) >> 1)
 				}
 				for i := 0; i < int(width)*2; i++ {
-					textBuffer[y][int(x)*2+i] = (*buffer)[i]
+					p.textBuffer[y][int(x)*2+i] = (*buffer)[i]
 				}
 				for i := 0; i < int(width); i++ {
-					redrawChar(int(x)+i, int(y))
+					p.redrawChar(int(x)+i, int(y))
 				}
 			}
 
-			VideoUpdateRequested.Store(true)
+			p.videoUpdateRequested.Store(true)
 		})
 	} else {
 		// wait for all async video writes to end
-		MainThreadSync(func() {
+		p.MainThreadSync(func() {
 
 		})
 		*buffer = make([]byte, width*2)
-		copy(*buffer, textBuffer[y][x*2:(x+width)*2])
+		copy(*buffer, p.textBuffer[y][x*2:(x+width)*2])
 	}
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePlatform struct {
+	DummyPlatform
+	keys   []byte
+	idles  []IdleMode
+	sounds []string
+}
+
+func (f *fakePlatform) Video() PlatformVideo { return f }
+func (f *fakePlatform) Input() PlatformInput { return f }
+func (f *fakePlatform) Timer() PlatformTimer { return f }
+func (f *fakePlatform) Audio() PlatformAudio { return f }
+
+func (f *fakePlatform) KeyPressed() bool {
+	return len(f.keys) > 0
+}
+
+func (f *fakePlatform) ReadKey() byte {
+	k := f.keys[0]
+	f.keys = f.keys[1:]
+	return k
+}
+
+func (f *fakePlatform) Idle(mode IdleMode) {
+	f.idles = append(f.idles, mode)
+}
+
+func (f *fakePlatform) Queue(pattern string, clear bool) {
+	f.sounds = append(f.sounds, pattern)
+}
+
+// testPlatform makes p the platform for the rest of a test, and puts the
+// one before it back afterwards, along with the input state and the text
+// window's place, which the test is then free to change.
+func testPlatform(t *testing.T, p Platform) {
+	current, video, input, timer, audio := CurrentPlatform, platformVideo, platformInput, platformTimer, platformAudio
+	deltaX, deltaY, lastDeltaX, lastDeltaY := InputDeltaX, InputDeltaY, InputLastDeltaX, InputLastDeltaY
+	shiftPressed, shiftAccepted, keyPressed, keyBuffer := InputShiftPressed, InputShiftAccepted, InputKeyPressed, InputKeyBuffer
+	joystickEnabled, joystickMoved := InputJoystickEnabled, InputJoystickMoved
+	mouseEnabled, mouseX, mouseY := InputMouseEnabled, InputMouseX, InputMouseY
+	mouseCellX, mouseCellY, mouseMoved := InputMouseCellX, InputMouseCellY, InputMouseMoved
+	mouseButtons, mouseClicked, mouseWheel := InputMouseButtons, InputMouseClicked, InputMouseWheel
+	windowX, windowY, windowWidth, windowHeight := TextWindowX, TextWindowY, TextWindowWidth, TextWindowHeight
+	t.Cleanup(func() {
+		CurrentPlatform, platformVideo, platformInput, platformTimer, platformAudio = current, video, input, timer, audio
+		InputDeltaX, InputDeltaY, InputLastDeltaX, InputLastDeltaY = deltaX, deltaY, lastDeltaX, lastDeltaY
+		InputShiftPressed, InputShiftAccepted, InputKeyPressed, InputKeyBuffer = shiftPressed, shiftAccepted, keyPressed, keyBuffer
+		InputJoystickEnabled, InputJoystickMoved = joystickEnabled, joystickMoved
+		InputMouseEnabled, InputMouseX, InputMouseY = mouseEnabled, mouseX, mouseY
+		InputMouseCellX, InputMouseCellY, InputMouseMoved = mouseCellX, mouseCellY, mouseMoved
+		InputMouseButtons, InputMouseClicked, InputMouseWheel = mouseButtons, mouseClicked, mouseWheel
+		TextWindowX, TextWindowY, TextWindowWidth, TextWindowHeight = windowX, windowY, windowWidth, windowHeight
+	})
+	PlatformSet(p)
+}
+
+func TestPlatformFake(t *testing.T) {
+	assert := assert.New(t)
+	fake := &fakePlatform{DummyPlatform: *NewDummyPlatform()}
+	testPlatform(t, fake)
+
+	VideoWriteText(2, 3, 0x1E, "Hi")
+	assert.Equal(byte('H'), fake.textBuffer[3][4])
+	assert.Equal(byte(0x1E), fake.textBuffer[3][5])
+
+	// VideoConfigure only idles while no key is pressed.
+	fake.keys = []byte{'m'}
+	assert.True(VideoConfigure())
+	assert.True(VideoMonochrome)
+	assert.Empty(fake.idles)
+	VideoMonochrome = false
+
+	SoundEnabled = true
+	SoundBlockQueueing = false
+	SoundIsPlaying = false
+	SoundQueue(1, "\x30\x01")
+	assert.Equal([]string{"\x30\x01"}, fake.sounds)
+	SoundClearQueue()
+}
+
+func TestPlatformRegistry(t *testing.T) {
+	assert := assert.New(t)
+	assert.Contains(PlatformNames(), "dummy")
+	p, err := PlatformNew("dummy")
+	assert.Nil(err)
+	assert.IsType(&DummyPlatform{}, p)
+	_, err = PlatformNew("nonexistent")
+	assert.Equal(ErrPlatformUnknown, err)
+}
+
+type mousePlatform struct {
+	fakePlatform
+	mouse []TMouseEvent
+}
+
+func (f *mousePlatform) Input() PlatformInput { return f }
+
+func (f *mousePlatform) ReadMouse() []TMouseEvent {
+	events := f.mouse
+	f.mouse = nil
+	return events
+}
+
+func TestPlatformMouse(t *testing.T) {
+	assert := assert.New(t)
+	testPlatform(t, NewDummyPlatform())
+	assert.False(InputInitMouse())
+	fake := &mousePlatform{fakePlatform: fakePlatform{DummyPlatform: *NewDummyPlatform()}}
+	testPlatform(t, fake)
+	assert.True(InputInitMouse())
+
+	// As a pointer.
+	fake.mouse = []TMouseEvent{{X: 10, Y: 5}, {X: 10, Y: 5, Buttons: MOUSE_BUTTON_LEFT}}
+	InputUpdate()
+	assert.True(InputMouseMoved)
+	assert.Equal(int16(10), InputMouseCellX)
+	assert.Equal(byte(MOUSE_BUTTON_LEFT), InputMouseClicked)
+	InputUpdate()
+	assert.False(InputMouseMoved)
+	assert.Zero(InputMouseClicked)
+	assert.Equal(byte(MOUSE_BUTTON_LEFT), InputMouseButtons)
+	assert.Zero(InputDeltaX)
+
+	// To play, as the original mouse mode did.
+	InputMouseEnabled = true
+	InputShiftAccepted = false
+	fake.mouse = []TMouseEvent{{X: 12, Y: 5, MoveX: 40, Buttons: MOUSE_BUTTON_LEFT}, {X: 13, Y: 5, MoveX: 30, Buttons: MOUSE_BUTTON_LEFT}}
+	InputUpdate()
+	assert.Equal(int16(1), InputDeltaX)
+	assert.True(InputShiftPressed)
+
+	// Clicking lines in a text window.
+	TextWindowX, TextWindowY, TextWindowWidth, TextWindowHeight = 5, 3, 50, 18
+	state := NewTextWindowState()
+	for i := 0; i < 10; i++ {
+		state.Append("line")
+	}
+	InputKeyPressed, InputDeltaY = '\x00', 0
+	InputMouseCellX, InputMouseCellY = 20, 14
+	InputMouseClicked = MOUSE_BUTTON_LEFT
+	state.mouseInput()
+	assert.Equal(int16(1), InputDeltaY)
+	InputDeltaY = 0
+	InputMouseCellY = 13
+	state.mouseInput()
+	assert.Equal(byte(KEY_ENTER), InputKeyPressed)
+	InputKeyPressed = '\x00'
+	InputMouseClicked = 0
+	InputMouseWheel = -1
+	state.mouseInput()
+	assert.Equal(int16(3), InputDeltaY)
+}
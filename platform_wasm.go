@@ -3,52 +3,73 @@
 package main
 
 import (
-	_ "embed"
 	"sync"
 	"sync/atomic"
 	"syscall/js"
 	"time"
 )
 
-var FrameTickCond = sync.NewCond(&sync.Mutex{})
-var PitTickCond = sync.NewCond(&sync.Mutex{})
-var VideoUpdateRequested atomic.Bool
-
-var timerTicks int = 0
-
-func TimerTicks() int {
-	return timerTicks
+type WasmPlatform struct {
+	frameTickCond        *sync.Cond
+	pitTickCond          *sync.Cond
+	videoUpdateRequested atomic.Bool
+	timerTicks           int
+	frameTicker          chan bool
+	tickerDone           chan bool
+	audio                *AudioSimulatorState
+	audioSlice           []byte
+	textBuffer           []byte
+	textColumns          int
+}
+
+func init() {
+	PlatformRegister("wasm", 100, func() Platform {
+		return NewWasmPlatform()
+	})
+}
+
+func NewWasmPlatform() *WasmPlatform {
+	return &WasmPlatform{
+		frameTickCond: sync.NewCond(&sync.Mutex{}),
+		pitTickCond:   sync.NewCond(&sync.Mutex{}),
+		frameTicker:   make(chan bool),
+		tickerDone:    make(chan bool),
+		audio:         NewAudioSimulatorNearest(48000, 32),
+		audioSlice:    make([]byte, 2048),
+		textBuffer:    make([]byte, 4000),
+		textColumns:   80,
+	}
 }
 
-func MemAvail() int32 {
-	// no-op
-	return 655360
-}
+func (w *WasmPlatform) Video() PlatformVideo { return w }
+func (w *WasmPlatform) Input() PlatformInput { return w }
+func (w *WasmPlatform) Timer() PlatformTimer { return w }
+func (w *WasmPlatform) Audio() PlatformAudio { return w.audio }
 
-func SetCBreak(v bool) {
-	// no-op
+func (w *WasmPlatform) TimerTicks() int {
+	return w.timerTicks
 }
 
-func Idle(mode IdleMode) {
+func (w *WasmPlatform) Idle(mode IdleMode) {
 	switch mode {
 	case IdleUntilFrame:
-		FrameTickCond.L.Lock()
-		FrameTickCond.Wait()
-		FrameTickCond.L.Unlock()
+		w.frameTickCond.L.Lock()
+		w.frameTickCond.Wait()
+		w.frameTickCond.L.Unlock()
 	case IdleUntilPit:
-		PitTickCond.L.Lock()
-		PitTickCond.Wait()
-		PitTickCond.L.Unlock()
+		w.pitTickCond.L.Lock()
+		w.pitTickCond.Wait()
+		w.pitTickCond.L.Unlock()
 	case IdleMinimal:
 		time.Sleep(1 * time.Nanosecond)
 	}
 }
 
-func Delay(ms uint32) {
+func (w *WasmPlatform) Delay(ms uint32) {
 	time.Sleep(time.Duration(ms) * time.Millisecond)
 }
 
-func KeysUpdateModifiers() {
+func (w *WasmPlatform) UpdateModifiers() {
 	v := js.Global().Get("ozg_keymod").Invoke().Int()
 	KeysRightShiftHeld = false
 	KeysLeftShiftHeld = (v & 0x01) != 0
@@ -57,77 +78,66 @@ func KeysUpdateModifiers() {
 	KeysAltHeld = (v & 0x08) != 0
 }
 
-func KeyPressed() bool {
+func (w *WasmPlatform) KeyPressed() bool {
 	return js.Global().Get("ozg_key").Invoke(false).Int() >= 0
 }
 
-func ReadKey() byte {
+func (w *WasmPlatform) ReadKey() byte {
 	return byte(js.Global().Get("ozg_key").Invoke(true).Int())
 }
 
-var frameTicker = make(chan bool)
-var tickerDone = make(chan bool)
-var audioSlice = make([]byte, 2048)
-
-func main() {
-	IVideoSetMode(80)
+func (w *WasmPlatform) Run(main func()) error {
+	w.SetMode(80)
 
 	js.Global().Set("ozg_videoRenderCommunicate", js.FuncOf(func(this js.Value, args []js.Value) any {
-		frameTicker <- true
+		w.frameTicker <- true
 
 		data := args[0]
 		force := args[1].Truthy()
 
-		requested := VideoUpdateRequested.Swap(false)
+		requested := w.videoUpdateRequested.Swap(false)
 		if force || requested {
-			js.CopyBytesToJS(data, textBuffer)
+			js.CopyBytesToJS(data, w.textBuffer)
 			return true
 		} else {
 			return false
 		}
 	}))
 
-	CurrentAudioSimulator = NewAudioSimulatorNearest(48000, 32)
-
 	js.Global().Set("ozg_audioCallback", js.FuncOf(func(this js.Value, args []js.Value) any {
-		CurrentAudioSimulator.Simulate(audioSlice)
-		js.CopyBytesToJS(args[0], audioSlice)
+		w.audio.Simulate(w.audioSlice)
+		js.CopyBytesToJS(args[0], w.audioSlice)
 		return nil
 	}))
 
 	js.Global().Get("ozg_init").Invoke()
 
-	Delay(33)
+	w.Delay(33)
 
 	pitTicker := time.NewTicker(55 * time.Millisecond)
 
 	go func() {
 		for {
 			select {
-			case <-frameTicker:
-				FrameTickCond.Broadcast()
+			case <-w.frameTicker:
+				w.frameTickCond.Broadcast()
 			case <-pitTicker.C:
 				SoundTimerHandler()
-				timerTicks++
-				PitTickCond.Broadcast()
-			case <-tickerDone:
+				w.timerTicks++
+				w.pitTickCond.Broadcast()
+			case <-w.tickerDone:
 				return
 			}
 		}
 	}()
 
-	ZZTMain()
+	main()
 
 	pitTicker.Stop()
-	tickerDone <- true
+	w.tickerDone <- true
+	return nil
 }
 
-//go:embed ascii.chr
-var charsetData []byte
-var textBuffer = make([]byte, 4000)
-var textColumns int = 80
-var blinkState bool = false
-
 var palette = []int{
 	0x000000,
 	0x0000AA,
@@ -153,31 +163,31 @@ func createBytesJS(data []byte) js.Value {
 	return dst
 }
 
-func IVideoSetMode(columns int) {
+func (w *WasmPlatform) SetMode(columns int) {
 	js.Global().Get("ozg_setCharset").Invoke(8, 14, createBytesJS(charsetData))
 	// js.Global().Get("ozg_setPalette").Invoke(palette)
-	textColumns = columns
+	w.textColumns = columns
 }
 
-func IVideoClrScr(backgroundColor uint8) {
+func (w *WasmPlatform) ClrScr(backgroundColor uint8) {
 	for iy := 0; iy < 25; iy++ {
-		for ix := 0; ix < textColumns; ix++ {
-			textBuffer[iy*160+ix*2] = ' '
-			textBuffer[iy*160+ix*2+1] = backgroundColor << 4
+		for ix := 0; ix < w.textColumns; ix++ {
+			w.textBuffer[iy*160+ix*2] = ' '
+			w.textBuffer[iy*160+ix*2+1] = backgroundColor << 4
 		}
 	}
-	VideoUpdateRequested.Store(true)
+	w.videoUpdateRequested.Store(true)
 }
 
-func IVideoWriteText(x, y int16, color byte, text string) {
+func (w *WasmPlatform) WriteText(x, y int16, color byte, text string) {
 	if y < 0 || y >= 25 {
 		return
 	}
 	for i := 0; i < len(text); i++ {
-		textBuffer[y*160+x*2] = text[i]
-		textBuffer[y*160+x*2+1] = color
+		w.textBuffer[y*160+x*2] = text[i]
+		w.textBuffer[y*160+x*2+1] = color
 		x++
-		if x >= int16(textColumns) {
+		if x >= int16(w.textColumns) {
 			x = 0
 			y++
 			if y >= 25 {
@@ -185,26 +195,26 @@ func IVideoWriteText(x, y int16, color byte, text string) {
 			}
 		}
 	}
-	VideoUpdateRequested.Store(true)
+	w.videoUpdateRequested.Store(true)
 }
 
-func IVideoSetCursorVisible(v bool) {
+func (w *WasmPlatform) SetCursorVisible(v bool) {
 	// stub
 }
 
-func VideoMove(x, y, width int16, buffer *[]byte, toVideo bool) {
+func (w *WasmPlatform) Move(x, y, width int16, buffer *[]byte, toVideo bool) {
 	if toVideo {
 		if buffer != nil {
 			if width > int16(len(*buffer)>>1) {
 				width = int16(len(*buffer) >> 1)
 			}
 			for i := 0; i < int(width)*2; i++ {
-				textBuffer[int(y)*160+int(x)*2+i] = (*buffer)[i]
+				w.textBuffer[int(y)*160+int(x)*2+i] = (*buffer)[i]
 			}
 		}
-		VideoUpdateRequested.Store(true)
+		w.videoUpdateRequested.Store(true)
 	} else {
 		*buffer = make([]byte, width*2)
-		copy(*buffer, textBuffer[int(y)*160+int(x)*2:int(y)*160+(int(x)+int(width))*2])
+		copy(*buffer, w.textBuffer[int(y)*160+int(x)*2:int(y)*160+(int(x)+int(width))*2])
 	}
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Session recording - asciicast output and input logs
+//
+// /A:<file> records the screen to an asciicast v2 file.
+// /I:<file> records every key read and mouse event, with the PIT tick it
+// was read on.
+// /P:<file> plays back an input log instead of reading the keyboard.
+//
+// Playing back an input log on the dummy platform, which runs on a virtual
+// clock, converts it to an asciicast offline:
+//
+//	openzoo-go /B:dummy TOWN /P:session.log /A:session.cast
+
+const (
+	inputLogMagic = "OpenZoo/Go input log 2"
+	// Logs from before mouse events were recorded hold keys only.
+	inputLogMagicKeys = "OpenZoo/Go input log 1"
+)
+
+type (
+	TInputLogEntry struct {
+		Tick  int
+		Key   byte
+		Shift bool
+	}
+	TInputLogMouse struct {
+		Tick  int
+		Event TMouseEvent
+	}
+	TInputLog struct {
+		Seed    uint32
+		Entries []TInputLogEntry
+		// HasMouse is set if the session was recorded with a mouse, so that
+		// it is played back with one.
+		HasMouse bool
+		Mouse    []TInputLogMouse
+		EndTick  int
+	}
+)
+
+var ErrInputLogFormat = errors.New("Not an input log!")
+
+var (
+	RecordingAsciicast   *AsciicastWriter
+	recordingInputFile   *os.File
+	recordingInputWriter *bufio.Writer
+	playbackInput        *TInputLog
+	playbackInputPos     int
+	playbackMousePos     int
+	playbackShift        bool
+	recordingStartTime   = time.Now()
+
+	// RecordingClock gives the time since the start of the session, as
+	// written to asciicast files.
+	RecordingClock = func() time.Duration {
+		return time.Since(recordingStartTime)
+	}
+)
+
+func InputLogRead(filename string) (log *TInputLog, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || scanner.Text() != inputLogMagic && scanner.Text() != inputLogMagicKeys {
+		return nil, ErrInputLogFormat
+	}
+	log = &TInputLog{EndTick: -1}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "seed" && len(fields) == 2:
+			seed, convErr := strconv.ParseUint(fields[1], 10, 32)
+			if convErr != nil {
+				return nil, ErrInputLogFormat
+			}
+			log.Seed = uint32(seed)
+		case fields[0] == "key" && len(fields) == 4:
+			log.Entries = append(log.Entries, TInputLogEntry{
+				Tick:  Val(fields[1]),
+				Key:   byte(Val(fields[2])),
+				Shift: fields[3] == "1",
+			})
+		case fields[0] == "mouse" && len(fields) == 1:
+			log.HasMouse = true
+		case fields[0] == "mouse" && len(fields) == 8:
+			log.Mouse = append(log.Mouse, TInputLogMouse{
+				Tick: Val(fields[1]),
+				Event: TMouseEvent{
+					X: int16(Val(fields[2])), Y: int16(Val(fields[3])),
+					MoveX: int16(Val(fields[4])), MoveY: int16(Val(fields[5])),
+					Buttons: byte(Val(fields[6])),
+					Wheel:   int16(Val(fields[7])),
+				},
+			})
+		case fields[0] == "end" && len(fields) == 2:
+			log.EndTick = Val(fields[1])
+		default:
+			return nil, ErrInputLogFormat
+		}
+	}
+	return log, scanner.Err()
+}
+
+func inputLogWrite(s string) {
+	recordingInputWriter.WriteString(s + "\n")
+	// Flushed every time, so that a session which is killed is not lost.
+	recordingInputWriter.Flush()
+}
+
+func recordingOpen(kind byte, filename string) (err error) {
+	switch kind {
+	case 'A':
+		RecordingAsciicast, err = AsciicastCreate(filename, RecordingClock)
+		if err == nil {
+			VideoListener = RecordingAsciicast
+		}
+	case 'I':
+		recordingInputFile, err = os.Create(filename)
+		if err == nil {
+			recordingInputWriter = bufio.NewWriter(recordingInputFile)
+			inputLogWrite(inputLogMagic)
+			inputLogWrite("seed " + strconv.FormatUint(uint64(RandSeed), 10))
+			if PlatformHasMouse() {
+				inputLogWrite("mouse")
+			}
+		}
+	case 'P':
+		playbackInput, err = InputLogRead(filename)
+		if err == nil {
+			playbackInputPos = 0
+			playbackMousePos = 0
+			RandSeed = playbackInput.Seed
+		}
+	}
+	return
+}
+
+// RecordingStart handles the recording switches. It is called after the
+// random number generator is seeded, as the seed is part of an input log.
+func RecordingStart() {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && pArg[2] == ':' {
+			if err := recordingOpen(UpCase(pArg[1]), pArg[3:]); err != nil {
+				fmt.Fprintln(os.Stderr, pArg[3:]+": "+err.Error())
+			}
+		}
+	}
+}
+
+func RecordingStop() {
+	if recordingInputWriter != nil {
+		inputLogWrite("end " + strconv.Itoa(TimerTicks()))
+		recordingInputFile.Close()
+		recordingInputWriter = nil
+	}
+	if RecordingAsciicast != nil {
+		if err := RecordingAsciicast.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		if VideoListener == RecordingAsciicast {
+			VideoListener = nil
+		}
+		RecordingAsciicast = nil
+	}
+}
+
+// InputPlaybackFinished returns true once every key and mouse event in the
+// input log being played back has been read and its end tick has passed.
+func InputPlaybackFinished() bool {
+	return playbackInput != nil && playbackInputPos >= len(playbackInput.Entries) &&
+		playbackMousePos >= len(playbackInput.Mouse) && TimerTicks() >= playbackInput.EndTick
+}
+
+func KeyPressed() bool {
+	if playbackInput != nil {
+		return playbackInputPos < len(playbackInput.Entries) &&
+			playbackInput.Entries[playbackInputPos].Tick <= TimerTicks()
+	}
+	return IKeyPressed()
+}
+
+func ReadKey() byte {
+	if playbackInput != nil {
+		if !KeyPressed() {
+			return 0
+		}
+		entry := playbackInput.Entries[playbackInputPos]
+		playbackInputPos++
+		playbackShift = entry.Shift
+		return entry.Key
+	}
+	key := IReadKey()
+	if recordingInputWriter != nil {
+		IKeysUpdateModifiers()
+		shift := "0"
+		if KeysShiftHeld {
+			shift = "1"
+		}
+		inputLogWrite("key " + strconv.Itoa(TimerTicks()) + " " + strconv.Itoa(int(key)) + " " + shift)
+	}
+	return key
+}
+
+// ReadMouse returns the mouse events since the last call.
+func ReadMouse() (events []TMouseEvent) {
+	if playbackInput != nil {
+		for playbackMousePos < len(playbackInput.Mouse) && playbackInput.Mouse[playbackMousePos].Tick <= TimerTicks() {
+			events = append(events, playbackInput.Mouse[playbackMousePos].Event)
+			playbackMousePos++
+		}
+		return
+	}
+	events = IReadMouse()
+	if recordingInputWriter != nil {
+		for _, e := range events {
+			inputLogWrite(fmt.Sprintf("mouse %d %d %d %d %d %d %d", TimerTicks(), e.X, e.Y, e.MoveX, e.MoveY, e.Buttons, e.Wheel))
+		}
+	}
+	return
+}
+
+func KeysUpdateModifiers() {
+	if playbackInput != nil {
+		KeysShiftHeld = playbackShift
+		KeysLeftShiftHeld = playbackShift
+		KeysRightShiftHeld = false
+		KeysCtrlHeld = false
+		KeysAltHeld = false
+		return
+	}
+	IKeysUpdateModifiers()
+}
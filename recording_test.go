@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInputLogMouse(t *testing.T) {
+	assert := assert.New(t)
+	fake := &mousePlatform{fakePlatform: fakePlatform{DummyPlatform: *NewDummyPlatform()}}
+	testPlatform(t, fake)
+	filename := filepath.Join(t.TempDir(), "session.log")
+	defer func() { playbackInput = nil }()
+
+	assert.Nil(recordingOpen('I', filename))
+	fake.mouse = []TMouseEvent{{X: 10, Y: 5, MoveX: -3, Buttons: MOUSE_BUTTON_LEFT}}
+	ReadMouse()
+	fake.time = 3 * dummyPitInterval
+	fake.mouse = []TMouseEvent{{X: 10, Y: 6, Wheel: -1}}
+	ReadMouse()
+	RecordingStop()
+
+	// Mouse events are played back on the tick they were read on, and with
+	// a mouse, whether the platform has one or not.
+	testPlatform(t, NewDummyPlatform())
+	assert.Nil(recordingOpen('P', filename))
+	assert.True(InputInitMouse())
+	assert.Equal([]TMouseEvent{{X: 10, Y: 5, MoveX: -3, Buttons: MOUSE_BUTTON_LEFT}}, ReadMouse())
+	assert.Empty(ReadMouse())
+	assert.False(InputPlaybackFinished())
+	CurrentPlatform.(*DummyPlatform).time = 3 * dummyPitInterval
+	assert.Equal([]TMouseEvent{{X: 10, Y: 6, Wheel: -1}}, ReadMouse())
+	assert.True(InputPlaybackFinished())
+
+	// Logs from before mouse events were recorded play back without one.
+	assert.Nil(os.WriteFile(filename, []byte(inputLogMagicKeys+"\nseed 1\nkey 2 107 0\nend 4\n"), 0o644))
+	assert.Nil(recordingOpen('P', filename))
+	assert.False(InputInitMouse())
+	assert.Len(playbackInput.Entries, 1)
+}
@@ -16,6 +16,7 @@ var (
 	SoundBufferPos          int16
 	SoundIsPlaying          bool
 	SoundDrumTable          [10][]uint16
+	SoundQueueListener      func(pattern string, clear bool)
 )
 
 // implementation uses: Crt, Dos
@@ -27,16 +28,18 @@ func SoundQueue(priority int16, pattern string) {
 			SoundBuffer = pattern
 			SoundBufferPos = 1
 			SoundDurationCounter = 1
-			if CurrentAudioSimulator != nil {
-				CurrentAudioSimulator.Queue(pattern, true)
+			IAudioQueue(pattern, true)
+			if SoundQueueListener != nil {
+				SoundQueueListener(pattern, true)
 			}
 		} else {
 			SoundBuffer = Copy(SoundBuffer, SoundBufferPos, Length(SoundBuffer)-SoundBufferPos+1)
 			SoundBufferPos = 1
 			if Length(SoundBuffer)+Length(pattern) < 255 {
 				SoundBuffer += pattern
-				if CurrentAudioSimulator != nil {
-					CurrentAudioSimulator.Queue(pattern, false)
+				IAudioQueue(pattern, false)
+				if SoundQueueListener != nil {
+					SoundQueueListener(pattern, false)
 				}
 			}
 		}
@@ -147,9 +150,7 @@ func SoundTimerHandler() {
 		}
 	}
 
-	if CurrentAudioSimulator != nil {
-		CurrentAudioSimulator.OnPitTick()
-	}
+	IAudioPitTick()
 }
 
 func SoundCountTicks(pattern string) int {
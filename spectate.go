@@ -0,0 +1,456 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Spectator server - read-only streaming of the text screen and sound
+//
+// A single listener serves three kinds of clients:
+//   - web browsers, which get the viewer page from "/",
+//   - WebSocket clients on "/ws", which get binary screen and sound messages,
+//   - plain TCP/telnet clients, which get the screen as ANSI escape codes.
+//
+// WebSocket messages (all integers little-endian):
+//   - SPECTATE_MSG_SCREEN, columns, then runs of (y, x, count, count * (char, attr))
+//   - SPECTATE_MSG_SOUND, clear, then steps of (frequency in Hz, duration in ms)
+//     as uint16 pairs; a frequency of 0 is silence.
+//
+// The game side only ever copies the screen and does non-blocking sends, so
+// slow spectators skip frames instead of holding up the tick loop.
+
+const (
+	SPECTATE_MSG_SCREEN = 1
+	SPECTATE_MSG_SOUND  = 2
+
+	spectateSniffTimeout = 300 * time.Millisecond
+	spectateWriteTimeout = 10 * time.Second
+	spectateSoundQueue   = 32
+	spectateWsGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+//go:embed spectator.html
+var spectatorViewerHtml []byte
+
+type (
+	SpectatorServer struct {
+		listener net.Listener
+		mutex    sync.Mutex
+		screen   *TTextBuffer
+		columns  int
+		clients  map[*spectatorClient]bool
+		served   sync.WaitGroup // clients still being served
+		closed   bool
+	}
+	spectatorClient struct {
+		wake   chan bool
+		sounds chan []byte
+		done   chan bool
+	}
+)
+
+var Spectators *SpectatorServer
+
+// SpectatorListenAddress returns the address given with /S:, if any.
+func SpectatorListenAddress() string {
+	for i := 1; i < len(os.Args); i++ {
+		pArg := os.Args[i]
+		if len(pArg) > 3 && pArg[0] == '/' && UpCase(pArg[1]) == 'S' && pArg[2] == ':' {
+			return pArg[3:]
+		}
+	}
+	return ""
+}
+
+func SpectatorStart(addr string) (*SpectatorServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &SpectatorServer{
+		listener: ln,
+		screen:   &TTextBuffer{},
+		columns:  80,
+		clients:  make(map[*spectatorClient]bool),
+	}
+	go s.acceptLoop()
+	return s, nil
+}
+
+func (s *SpectatorServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting spectators, and tells those watching that the game
+// is over before returning.
+func (s *SpectatorServer) Close() {
+	s.listener.Close()
+	s.mutex.Lock()
+	for c := range s.clients {
+		close(c.done)
+	}
+	s.clients = make(map[*spectatorClient]bool)
+	s.closed = true
+	s.mutex.Unlock()
+	s.served.Wait()
+}
+
+// PublishScreen makes a copy of the given screen available to spectators.
+func (s *SpectatorServer) PublishScreen(buf *TTextBuffer, columns int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if columns == s.columns && *buf == *s.screen {
+		return
+	}
+	screen := *buf
+	s.screen = &screen
+	s.columns = columns
+	for c := range s.clients {
+		select {
+		case c.wake <- true:
+		default:
+		}
+	}
+}
+
+// PublishSound forwards a queued sound pattern; it has the signature of
+// SoundQueueListener.
+func (s *SpectatorServer) PublishSound(pattern string, clear bool) {
+	if !SoundEnabled {
+		return
+	}
+	msg := SpectatorEncodeSound(pattern, clear)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for c := range s.clients {
+		select {
+		case c.sounds <- msg:
+		default:
+		}
+	}
+}
+
+func (s *SpectatorServer) latestScreen() (*TTextBuffer, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.screen, s.columns
+}
+
+func (s *SpectatorServer) addClient() *spectatorClient {
+	c := &spectatorClient{
+		wake:   make(chan bool, 1),
+		sounds: make(chan []byte, spectateSoundQueue),
+		done:   make(chan bool),
+	}
+	c.wake <- true
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.served.Add(1)
+	if s.closed {
+		// Too late to watch; it is sent away at once.
+		close(c.done)
+	} else {
+		s.clients[c] = true
+	}
+	return c
+}
+
+func (s *SpectatorServer) removeClient(c *spectatorClient) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.clients[c] {
+		delete(s.clients, c)
+		close(c.done)
+	}
+	s.served.Done()
+}
+
+func (s *SpectatorServer) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve tells HTTP clients apart from terminals by the first byte sent.
+// Terminals often send nothing at all, so a timeout also means ANSI.
+func (s *SpectatorServer) serve(conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(spectateSniffTimeout))
+	first, err := br.Peek(1)
+	conn.SetReadDeadline(time.Time{})
+	if err == nil && first[0] >= 'A' && first[0] <= 'Z' {
+		s.serveHttp(conn, br)
+	} else if err == nil || os.IsTimeout(err) {
+		s.serveAnsi(conn, br, err == nil && first[0] == TELNET_IAC)
+	}
+}
+
+func (s *SpectatorServer) serveHttp(conn net.Conn, br *bufio.Reader) {
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return
+	}
+	switch {
+	case req.URL.Path == "/ws" && strings.EqualFold(req.Header.Get("Upgrade"), "websocket"):
+		key := req.Header.Get("Sec-WebSocket-Key")
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: "+SpectatorWebSocketAccept(key)+"\r\n\r\n")
+		s.serveWebSocket(conn, br)
+	case req.URL.Path == "/":
+		spectateWriteHttp(conn, "200 OK", "text/html; charset=utf-8", spectatorViewerHtml)
+	case req.URL.Path == "/charset.chr":
+		spectateWriteHttp(conn, "200 OK", "application/octet-stream", charsetData)
+	default:
+		spectateWriteHttp(conn, "404 Not Found", "text/plain", []byte("Not found"))
+	}
+}
+
+func spectateWriteHttp(conn net.Conn, status string, contentType string, body []byte) {
+	io.WriteString(conn, "HTTP/1.1 "+status+"\r\n"+
+		"Content-Type: "+contentType+"\r\n"+
+		"Content-Length: "+strconv.Itoa(len(body))+"\r\n"+
+		"Connection: close\r\n\r\n")
+	conn.Write(body)
+}
+
+// SpectatorWebSocketAccept computes the Sec-WebSocket-Accept handshake value.
+func SpectatorWebSocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + spectateWsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func spectateWsFrame(opcode byte, payload []byte) []byte {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	if len(payload) < 126 {
+		frame = append(frame, byte(len(payload)))
+	} else if len(payload) < 65536 {
+		frame = append(frame, 126, byte(len(payload)>>8), byte(len(payload)))
+	} else {
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	return append(frame, payload...)
+}
+
+// spectateWsReadLoop discards incoming messages, answering pings and close
+// requests, until the connection is closed.
+func spectateWsReadLoop(br *bufio.Reader, control chan []byte) {
+	defer close(control)
+	var header [2]byte
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return
+		}
+		opcode := header[0] & 0x0F
+		length := uint64(header[1] & 0x7F)
+		if length == 126 {
+			var ext [2]byte
+			if _, err := io.ReadFull(br, ext[:]); err != nil {
+				return
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		} else if length == 127 {
+			var ext [8]byte
+			if _, err := io.ReadFull(br, ext[:]); err != nil {
+				return
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask [4]byte
+		if (header[1] & 0x80) != 0 {
+			if _, err := io.ReadFull(br, mask[:]); err != nil {
+				return
+			}
+		}
+		if opcode < 8 {
+			if _, err := io.CopyN(io.Discard, br, int64(length)); err != nil {
+				return
+			}
+			continue
+		}
+		// Control frames are at most 125 bytes long.
+		if length > 125 {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i&3]
+		}
+		switch opcode {
+		case 8:
+			control <- spectateWsFrame(8, payload)
+			return
+		case 9:
+			control <- spectateWsFrame(10, payload)
+		}
+	}
+}
+
+func (s *SpectatorServer) serveWebSocket(conn net.Conn, br *bufio.Reader) {
+	c := s.addClient()
+	defer s.removeClient(c)
+
+	control := make(chan []byte, 4)
+	go spectateWsReadLoop(br, control)
+
+	var sent TTextBuffer
+	sentColumns := 0
+	for {
+		var frame []byte
+		select {
+		case <-c.wake:
+			screen, columns := s.latestScreen()
+			msg := SpectatorEncodeScreen(&sent, screen, columns, sentColumns != columns)
+			sentColumns = columns
+			if msg == nil {
+				continue
+			}
+			frame = spectateWsFrame(2, msg)
+		case msg := <-c.sounds:
+			frame = spectateWsFrame(2, msg)
+		case msg, ok := <-control:
+			if !ok {
+				return
+			}
+			frame = msg
+		case <-c.done:
+			conn.SetWriteDeadline(time.Now().Add(spectateWriteTimeout))
+			conn.Write(spectateWsFrame(8, nil))
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(spectateWriteTimeout))
+		if _, err := conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func (s *SpectatorServer) serveAnsi(conn net.Conn, br *bufio.Reader, telnet bool) {
+	c := s.addClient()
+	defer s.removeClient(c)
+
+	if telnet {
+		conn.Write([]byte{
+			TELNET_IAC, TELNET_WILL, TELNET_OPT_ECHO,
+			TELNET_IAC, TELNET_WILL, TELNET_OPT_SGA,
+		})
+	}
+	closed := make(chan bool)
+	go func() {
+		io.Copy(io.Discard, br)
+		close(closed)
+	}()
+
+	var out io.Writer = conn
+	if telnet {
+		out = doorTelnetWriter{conn}
+	}
+	encoder := NewAnsiEncoder(AnsiCharCP437)
+	var sent TTextBuffer
+	sentColumns := 0
+	for {
+		select {
+		case <-c.wake:
+		case <-c.sounds:
+			continue
+		case <-closed:
+			return
+		case <-c.done:
+			return
+		}
+		var sb strings.Builder
+		screen, columns := s.latestScreen()
+		if columns != sentColumns {
+			sb.WriteString("\x1b[?25l")
+			encoder.WriteFull(&sb, &sent, screen, columns)
+			sentColumns = columns
+		} else {
+			encoder.WriteDiff(&sb, &sent, screen, columns)
+		}
+		conn.SetWriteDeadline(time.Now().Add(spectateWriteTimeout))
+		if _, err := io.WriteString(out, sb.String()); err != nil {
+			return
+		}
+	}
+}
+
+// SpectatorEncodeScreen encodes the cells of cur which differ from prev (or
+// all cells, if full is set) as a screen message, updating prev. It returns
+// nil if nothing changed.
+func SpectatorEncodeScreen(prev, cur *TTextBuffer, columns int, full bool) []byte {
+	msg := []byte{SPECTATE_MSG_SCREEN, byte(columns)}
+	for iy := 0; iy < 25; iy++ {
+		ix := 0
+		for ix < columns {
+			if !full && prev[iy][ix*2] == cur[iy][ix*2] && prev[iy][ix*2+1] == cur[iy][ix*2+1] {
+				ix++
+				continue
+			}
+			runStart := ix
+			for ix < columns && (full || prev[iy][ix*2] != cur[iy][ix*2] || prev[iy][ix*2+1] != cur[iy][ix*2+1]) {
+				ix++
+			}
+			msg = append(msg, byte(iy), byte(runStart), byte(ix-runStart))
+			msg = append(msg, cur[iy][runStart*2:ix*2]...)
+		}
+	}
+	*prev = *cur
+	if len(msg) <= 2 {
+		return nil
+	}
+	return msg
+}
+
+// SpectatorEncodeSound converts a sound pattern, as produced by SoundParse,
+// into a list of tones, so that viewers need not know about the PIT.
+func SpectatorEncodeSound(pattern string, clear bool) []byte {
+	msg := []byte{SPECTATE_MSG_SOUND, 0}
+	if clear {
+		msg[1] = 1
+	}
+	for i := 0; i+1 < len(pattern); i += 2 {
+		note := byte(pattern[i])
+		duration := int(SoundDurationMultiplier) * int(byte(pattern[i+1])) * 55
+		if note >= 240 && note < 250 {
+			drum := SoundDrumTable[note-240]
+			for j := 0; j < len(drum) && duration > 0; j++ {
+				msg = binary.LittleEndian.AppendUint16(msg, drum[j])
+				msg = binary.LittleEndian.AppendUint16(msg, 1)
+				duration--
+			}
+			msg = binary.LittleEndian.AppendUint16(msg, 0)
+			msg = binary.LittleEndian.AppendUint16(msg, uint16(duration))
+		} else if note < 240 {
+			msg = binary.LittleEndian.AppendUint16(msg, uint16(SoundFreqTable[note]>>8))
+			msg = binary.LittleEndian.AppendUint16(msg, uint16(duration))
+		}
+	}
+	return msg
+}
@@ -0,0 +1,72 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorWebSocketAccept(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", SpectatorWebSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestSpectatorEncodeScreen(t *testing.T) {
+	assert := assert.New(t)
+	var prev, cur TTextBuffer
+	assert.Nil(SpectatorEncodeScreen(&prev, &cur, 80, false))
+
+	cur[3][10*2] = 'A'
+	cur[3][10*2+1] = 0x1F
+	cur[3][11*2] = 'B'
+	cur[3][11*2+1] = 0x1F
+	assert.Equal([]byte{SPECTATE_MSG_SCREEN, 80, 3, 10, 2, 'A', 0x1F, 'B', 0x1F},
+		SpectatorEncodeScreen(&prev, &cur, 80, false))
+	assert.Equal(cur, prev)
+	assert.Equal(2+25*(3+40*2), len(SpectatorEncodeScreen(&prev, &cur, 40, true)))
+}
+
+func TestSpectatorServerWebSocket(t *testing.T) {
+	assert := assert.New(t)
+	s, err := SpectatorStart("127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.Nil(err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	assert.Nil(err)
+	assert.Equal(101, resp.StatusCode)
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	// The first frame is always a full screen.
+	var header [4]byte
+	_, err = io.ReadFull(br, header[:])
+	assert.Nil(err)
+	assert.Equal(byte(0x82), header[0])
+	assert.Equal(byte(126), header[1])
+	assert.Equal(2+25*(3+80*2), int(header[2])<<8|int(header[3]))
+
+	// Closing the server closes the connection cleanly.
+	_, err = io.CopyN(io.Discard, br, int64(int(header[2])<<8|int(header[3])))
+	assert.Nil(err)
+	s.Close()
+	_, err = io.ReadFull(br, header[:2])
+	assert.Nil(err)
+	assert.Equal([]byte{0x88, 0}, header[:2])
+}
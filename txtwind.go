@@ -22,6 +22,15 @@ type (
 		Title          string
 		LoadedFilename string
 		ScreenCopy     [25][]byte
+		// ExitKeys lists extra keys (upper case) which end Select, leaving
+		// InputKeyPressed set.
+		ExitKeys string
+		// Highlight, if set, colors the lines shown by Edit, one color per
+		// character, and tells what is wrong with a line, if anything.
+		Highlight func(line string) (colors []byte, err string)
+		// Edits counts the changes Edit has made to the lines, for whatever
+		// Highlight works out from all of them to be kept until the next.
+		Edits int
 	}
 	TResourceDataHeader struct {
 		EntryCount int16
@@ -124,7 +133,9 @@ func (state *TTextWindowState) drawLine(lpos int16, withoutFormatting, viewingFi
 		VideoWriteText(TextWindowX+2, lineY, 0x1E, TextWindowStrInnerEmpty)
 	}
 	if lpos > 0 && int(lpos) <= len(state.Lines) {
-		if withoutFormatting {
+		if withoutFormatting && state.Highlight != nil {
+			state.drawHighlighted(TextWindowX+4, lineY, state.Lines[lpos-1])
+		} else if withoutFormatting {
 			VideoWriteText(TextWindowX+4, lineY, 0x1E, state.Lines[lpos-1])
 		} else {
 			textOffset = 1
@@ -162,6 +173,32 @@ func (state *TTextWindowState) drawLine(lpos int16, withoutFormatting, viewingFi
 
 }
 
+// drawHighlighted draws a line in the colors given by Highlight, marking it
+// if it has an error.
+func (state *TTextWindowState) drawHighlighted(x, y int16, line string) {
+	colors, err := state.Highlight(line)
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i == len(line) || colors[i] != colors[start] {
+			VideoWriteText(x+int16(start), y, colors[start], line[start:i])
+			start = i
+		}
+	}
+	if Length(err) != 0 {
+		VideoWriteText(x-1, y, 0x1C, "\x13")
+	}
+}
+
+// drawLineError shows what is wrong with the current line on the sidebar.
+func (state *TTextWindowState) drawLineError() {
+	SidebarClearLine(22)
+	SidebarClearLine(23)
+	if _, err := state.Highlight(state.Lines[state.LinePos-1]); Length(err) != 0 {
+		VideoWriteText(62, 22, 0x1C, "\x13 Error:")
+		VideoWriteText(62, 23, 0x1E, Copy(err, 1, 18))
+	}
+}
+
 func (state *TTextWindowState) Draw(withoutFormatting, viewingFile bool) {
 	var i int16
 	for i = 0; i <= TextWindowHeight-4; i++ {
@@ -216,6 +253,39 @@ func (state *TTextWindowState) Print() {
 	Close(Lst) */
 }
 
+// mouseLine returns the line under the mouse, or zero if it is not over one.
+func (state *TTextWindowState) mouseLine() int {
+	if InputMouseCellX < TextWindowX || InputMouseCellX >= TextWindowX+TextWindowWidth ||
+		InputMouseCellY < TextWindowY+3 || InputMouseCellY > TextWindowY+TextWindowHeight-1 {
+		return 0
+	}
+	line := int(InputMouseCellY-TextWindowY-TextWindowHeight/2-1) + state.LinePos
+	if line < 1 || line > len(state.Lines) {
+		return 0
+	}
+	return line
+}
+
+// mouseInput turns clicks and the wheel into the keys Select acts on:
+// clicking a line moves to it, and clicking it again is Enter. The right
+// button is Escape.
+func (state *TTextWindowState) mouseInput() {
+	if InputKeyPressed != '\x00' || InputDeltaY != 0 {
+		return
+	}
+	if InputMouseClicked&MOUSE_BUTTON_LEFT != 0 {
+		if line := state.mouseLine(); line == state.LinePos {
+			InputKeyPressed = KEY_ENTER
+		} else if line > 0 {
+			InputDeltaY = int16(line - state.LinePos)
+		}
+	} else if InputMouseClicked&MOUSE_BUTTON_RIGHT != 0 {
+		InputKeyPressed = KEY_ESCAPE
+	} else if InputMouseWheel != 0 {
+		InputDeltaY = -3 * InputMouseWheel
+	}
+}
+
 func (state *TTextWindowState) Select(hyperlinkAsSelect, viewingFile bool) {
 	var (
 		newLinePos int
@@ -228,6 +298,7 @@ func (state *TTextWindowState) Select(hyperlinkAsSelect, viewingFile bool) {
 	for {
 		Idle(IdleUntilFrame)
 		InputUpdate()
+		state.mouseInput()
 		newLinePos = state.LinePos
 		if InputDeltaY != 0 {
 			newLinePos += int(InputDeltaY)
@@ -308,6 +379,9 @@ func (state *TTextWindowState) Select(hyperlinkAsSelect, viewingFile bool) {
 		if InputKeyPressed == KEY_ESCAPE || InputKeyPressed == KEY_ENTER || InputShiftPressed {
 			break
 		}
+		if InputKeyPressed != '\x00' && strings.IndexByte(state.ExitKeys, UpCase(InputKeyPressed)) >= 0 {
+			break
+		}
 	}
 	if InputKeyPressed == KEY_ESCAPE {
 		InputKeyPressed = '\x00'
@@ -325,6 +399,7 @@ func (state *TTextWindowState) Edit() {
 		if len(state.Lines) > 1 {
 			state.Lines[state.LinePos-1] = ""
 			state.Lines = append(state.Lines[:state.LinePos-1], state.Lines[state.LinePos:]...)
+			state.Edits++
 			if state.LinePos > len(state.Lines) {
 				newLinePos = len(state.Lines)
 			} else {
@@ -348,6 +423,9 @@ func (state *TTextWindowState) Edit() {
 		} else {
 			VideoWriteText(77, 14, 0x1E, "off")
 		}
+		if state.Highlight != nil {
+			state.drawLineError()
+		}
 		if charPos >= Length(state.Lines[state.LinePos-1])+1 {
 			charPos = Length(state.Lines[state.LinePos-1]) + 1
 			VideoWriteText(charPos+TextWindowX+3, TextWindowY+TextWindowHeight/2+1, 0x70, " ")
@@ -356,6 +434,7 @@ func (state *TTextWindowState) Edit() {
 		}
 		InputReadWaitKey()
 		newLinePos = state.LinePos
+		lineBefore, countBefore := state.Lines[state.LinePos-1], len(state.Lines)
 		switch InputKeyPressed {
 		case KEY_UP:
 			newLinePos = state.LinePos - 1
@@ -415,6 +494,10 @@ func (state *TTextWindowState) Edit() {
 				}
 			}
 		}
+		// Every change is to the current line, or adds or removes one.
+		if len(state.Lines) != countBefore || state.Lines[state.LinePos-1] != lineBefore {
+			state.Edits++
+		}
 		if newLinePos < 1 {
 			newLinePos = 1
 		} else if newLinePos > len(state.Lines) {
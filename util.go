@@ -147,3 +147,19 @@ func Clamp[T constraints.Integer](x, minX, maxX T) T {
 		return x
 	}
 }
+
+func Min[T constraints.Integer](a, b T) T {
+	if a < b {
+		return a
+	} else {
+		return b
+	}
+}
+
+func Max[T constraints.Integer](a, b T) T {
+	if a > b {
+		return a
+	} else {
+		return b
+	}
+}
@@ -2,12 +2,27 @@ package main
 
 var VideoMonochrome bool = false
 
+// TVideoListener is told about every change made to the screen, after the
+// platform has carried it out.
+type TVideoListener interface {
+	VideoSetMode(columns int)
+	VideoClrScr(backgroundColor uint8)
+	VideoWriteText(x, y int16, color byte, text string)
+	VideoMove(x, y, width int16, buffer []byte)
+}
+
+var VideoListener TVideoListener
+
 func VideoInstall(columns int, backgroundColor uint8) {
 	if VideoMonochrome {
 		backgroundColor = 0
 	}
 	IVideoSetMode(columns)
 	IVideoClrScr(backgroundColor)
+	if VideoListener != nil {
+		VideoListener.VideoSetMode(columns)
+		VideoListener.VideoClrScr(backgroundColor)
+	}
 }
 
 func colorToBw(color byte) byte {
@@ -66,13 +81,30 @@ func VideoConfigure() bool {
 
 func VideoClrScr() {
 	IVideoClrScr(0)
+	if VideoListener != nil {
+		VideoListener.VideoClrScr(0)
+	}
+}
+
+func videoWriteText(x, y int16, color byte, text string) {
+	IVideoWriteText(x, y, color, text)
+	if VideoListener != nil {
+		VideoListener.VideoWriteText(x, y, color, text)
+	}
 }
 
 func VideoWriteText(x, y int16, color byte, text string) {
 	if VideoMonochrome {
 		color = colorToBw(color)
 	}
-	IVideoWriteText(x, y, color, text)
+	videoWriteText(x, y, color, text)
+}
+
+func VideoMove(x, y, width int16, buffer *[]byte, toVideo bool) {
+	IVideoMove(x, y, width, buffer, toVideo)
+	if toVideo && buffer != nil && VideoListener != nil {
+		VideoListener.VideoMove(x, y, width, *buffer)
+	}
 }
 
 func VideoShowCursor() {
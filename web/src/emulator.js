@@ -57,6 +57,8 @@ class Emulator {
             let chr = (event.key.length == 1) ? event.key.charCodeAt(0) : (keychrmap[event.keyCode] || 0);
             let key = keymap[event.key] || 0;
             if (key >= 0x46 && key <= 0x53) chr = 0;
+            // Ctrl+letter gives its control code, as on DOS (except Ctrl-A/B, which prefix extended keys).
+            if (event.ctrlKey && !event.altKey && /^[c-zC-Z]$/.test(event.key)) chr = event.key.toUpperCase().charCodeAt(0) - 64;
             if (chr > 0 || key > 0) {
                 curr_key = (chr > 0 && chr < 127) ? chr : (key + 128);
                 ret = true;
@@ -110,6 +110,7 @@ func ZZTMain() {
 	WorldFileDescs["BEST"] = "BEST       The Best of ZZT"
 	WorldFileDescs["TOUR"] = "TOUR       Guided Tour ZZT's Other Worlds"
 	Randomize()
+	RecordingStart()
 	SetCBreak(false)
 	InitialTextAttr = TextAttr
 	StartupWorldFileName = "TOWN"
@@ -127,6 +128,9 @@ func ZZTMain() {
 		TickSpeed = 4
 		DebugEnabled = false
 		SavedGameFileName = "SAVED"
+		if Length(PlayerName) != 0 {
+			SavedGameFileName = PlayerSaveFileName(PlayerName)
+		}
 		SavedBoardFileName = "TEMP"
 		GenerateTransitionTable()
 		WorldCreate()
@@ -143,4 +147,5 @@ func ZZTMain() {
 		WriteLn("")
 	}
 	VideoShowCursor()
+	RecordingStop()
 }